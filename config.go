@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configFileName is the config file discovered in the first target directory.
+const configFileName = ".pyreqs.toml"
+
+// Config holds the settings of a .pyreqs.toml file. Command-line flags
+// override every value set here.
+type Config struct {
+	Output         string            `toml:"output"`
+	Format         string            `toml:"format"`
+	Pin            string            `toml:"pin"`
+	ExcludeDirs    []string          `toml:"exclude_dirs"`
+	IgnoreModules  []string          `toml:"ignore_modules"`
+	IgnorePackages []string          `toml:"ignore_packages"`
+	Mappings       map[string]string `toml:"mappings"`
+	Markers        map[string]string `toml:"markers"`
+	Namespaces     map[string]int    `toml:"namespaces"`
+	Overrides      map[string]string `toml:"overrides"`
+}
+
+// loadConfig reads the config file at path, rejecting unknown keys so typos
+// do not go unnoticed.
+func loadConfig(path string) (*Config, error) {
+	var config Config
+	meta, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config '%s': %v", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key '%s' in config '%s'", undecoded[0], path)
+	}
+	return &config, nil
+}
+
+// findConfig returns the config file to load: the explicit path when given,
+// otherwise .pyreqs.toml in targetDir if it exists, or "" for none.
+func findConfig(explicit, targetDir string) string {
+	if explicit != "" {
+		return explicit
+	}
+	path := filepath.Join(targetDir, configFileName)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// parseLogLevel converts a -log-level value into a slog level.
+func parseLogLevel(value string) (slog.Level, error) {
+	switch value {
+	case "error":
+		return slog.LevelError, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	}
+	return 0, fmt.Errorf("unknown log level '%s' (want error, warn, info or debug)", value)
+}
+
+// newLogger returns a logger writing records at level and above to w, as
+// logfmt-style text or as one JSON object per line.
+func newLogger(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format '%s' (want text or json)", format)
+}
@@ -2,211 +2,840 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"regexp"
-	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/LaamiriOuail/go-pyreqs/pyreqs"
 )
 
-type RequirementsGenerator struct {
-	targetDir    string
-	outputFile   string
-	foundModules map[string]bool
+// version is the release of the tool, set at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// stringList is a flag.Value that collects every occurrence of a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Exit codes reported to the shell.
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitNotFound   = 2 // a target directory does not exist
+	exitPipMissing = 3 // pip, python or conda is not available
+	exitOutOfDate  = 4 // -check found a stale output file
+	exitUnresolved = 5 // -fail-on-unresolved found unresolved imports
+)
+
+// outOfDateError is returned by run when -check finds differences.
+type outOfDateError struct {
+	outputFile string
+}
+
+func (e *outOfDateError) Error() string {
+	return fmt.Sprintf("'%s' is out of date", e.outputFile)
+}
+
+// unresolvedError is returned by run with -fail-on-unresolved when imports
+// have no matching installed package.
+type unresolvedError struct {
+	modules []string
+}
+
+func (e *unresolvedError) Error() string {
+	return fmt.Sprintf("no installed package provides %s", strings.Join(e.modules, ", "))
+}
+
+// exitCode maps an error returned by run to the process exit code.
+func exitCode(err error) int {
+	var notFound *pyreqs.DirectoryNotFoundError
+	var missing *pyreqs.ExecutableNotFoundError
+	var outOfDate *outOfDateError
+	var unresolved *unresolvedError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &notFound):
+		return exitNotFound
+	case errors.As(err, &missing):
+		return exitPipMissing
+	case errors.As(err, &outOfDate):
+		return exitOutOfDate
+	case errors.As(err, &unresolved):
+		return exitUnresolved
+	default:
+		return exitError
+	}
+}
+
+// cliOptions holds the settings that only affect the command-line front end.
+type cliOptions struct {
+	dryRun bool
+	// yes overwrites existing output files without asking, and noClobber
+	// refuses to overwrite them.
+	yes       bool
+	noClobber bool
+	// watch regenerates the requirements whenever a scanned file changes.
+	watch bool
+	// perDir writes separate requirements into every service root.
+	perDir     bool
+	check      bool
+	allowEmpty bool
+	// failOnUnresolved makes unresolved imports an error.
+	failOnUnresolved bool
+	// stdin reads Python code from standard input instead of scanning
+	// directories, and stdout prints the requirements instead of writing a file.
+	stdin  bool
+	stdout bool
+	// quiet suppresses informational output; errors and warnings still go
+	// to stderr.
+	quiet bool
+	// logger receives warnings, per-file diagnostics and the scan summary.
+	logger *slog.Logger
+	// progress shows a running file count on stderr while scanning.
+	progress bool
+}
+
+// isTerminal reports whether f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printProgress overwrites the current stderr line with the scan progress.
+func printProgress(files, modules int) {
+	fmt.Fprintf(os.Stderr, "\rScanned %d files, found %d modules...", files, modules)
+}
+
+// printf prints an informational message unless quiet output is requested.
+func (cli cliOptions) printf(format string, args ...interface{}) {
+	if !cli.quiet {
+		fmt.Printf(format, args...)
+	}
 }
 
 func main() {
 	var outputFile string
-	flag.StringVar(&outputFile, "output", "requirements.txt", "Output file for requirements")
+	var devOutputFile string
+	var outputDir string
+	var since string
+	var excludeDirs stringList
+	var onlyPackages stringList
+	var ignoreModules stringList
+	var ignorePackages stringList
+	var constraintsFile string
+	var includePatterns stringList
+	var excludePatterns stringList
+	var useGitignore bool
+	var followSymlinks bool
+	var noRecursive bool
+	var depth int
+	var includeNotebooks bool
+	var dynamicImports bool
+	var excludeTypeChecking bool
+	var parser string
+	var scanDoctests bool
+	var scanEntryPoints bool
+	var pinStyle string
+	var sortOrder string
+	var source string
+	var format string
+	var pip string
+	var conda string
+	var python string
+	var noVenv bool
+	var pythonVersion string
+	var noCache bool
+	var freezeFile string
+	var pipTimeout time.Duration
+	var pipRetries int
+	var jobs int
+	var verbose bool
+	var logLevel string
+	var logFormat string
+	var generateHashes bool
+	var keepExtras bool
+	var stripLocal bool
+	var withDeps bool
+	var configFile string
+	var merge bool
+	var header bool
+	var includeUnresolved bool
+	var groupByDir bool
+	var groupDepth int
+	var groupCommon bool
+	var modulesOnly bool
+	var reportUnused bool
+	var templateFile string
+	var uncommentUnresolved bool
+	var cli cliOptions
+	flag.StringVar(&configFile, "config", "", "Config file (default: "+configFileName+" in the first target directory)")
+	flag.StringVar(&outputFile, "output", "", "Output file for requirements, or - for stdout (default depends on -format, e.g. requirements.txt)")
+	flag.StringVar(&outputDir, "output-dir", "", "Directory the output files are written to, created if missing; -output and -dev-output are relative to it")
+	flag.StringVar(&devOutputFile, "dev-output", "", "Write requirements only imported by test files to this file (e.g. requirements-dev.txt)")
+	flag.Var(&excludeDirs, "exclude-dir", "Directory name to skip while scanning (repeatable)")
+	flag.Var(&includePatterns, "include", "Glob of further file names to scan as Python code, e.g. '*.pyw' (repeatable)")
+	flag.Var(&excludePatterns, "exclude", "Glob of file names never to scan, e.g. '*_pb2.py' (repeatable)")
+	flag.BoolVar(&useGitignore, "use-gitignore", false, "Skip files and directories ignored by .gitignore")
+	flag.BoolVar(&followSymlinks, "follow-symlinks", false, "Also scan directories that symlinks point to")
+	flag.BoolVar(&noRecursive, "no-recursive", false, "Only scan the files directly in each target directory (same as -depth 0)")
+	flag.IntVar(&depth, "depth", -1, "Maximum number of directory levels scanned below each target, or -1 for no limit")
+	flag.BoolVar(&includeNotebooks, "include-notebooks", true, "Also scan Jupyter notebook (.ipynb) files")
+	flag.BoolVar(&dynamicImports, "dynamic-imports", false, "Also detect importlib.import_module(\"name\") and __import__(\"name\") calls")
+	flag.BoolVar(&scanEntryPoints, "scan-entrypoints", false, "Also add the modules of entry points (module:function) declared in setup.py, setup.cfg and pyproject.toml")
+	flag.BoolVar(&scanDoctests, "scan-doctests", false, "Also detect imports in '>>> import x' doctest examples inside docstrings")
+	flag.BoolVar(&excludeTypeChecking, "exclude-type-checking", false, "Skip imports inside 'if TYPE_CHECKING:' blocks")
+	flag.StringVar(&parser, "parser", "regex", "Import extraction: regex, or ast to parse each file with the Python interpreter's ast module (slower, falls back to regex)")
+	flag.StringVar(&pinStyle, "pin", "exact", "Version pinning style: exact, compatible, minimum, compatible-range, caret (-format poetry only, its default) or none")
+	flag.StringVar(&sortOrder, "sort", "name", "Requirement order: name (case-insensitive) or none (first import order)")
+	flag.StringVar(&source, "source", "freeze", "Installed package source: freeze (pip freeze), list (pip list --format=json), conda (conda list --export) or metadata (pip freeze with importlib.metadata import names)")
+	flag.StringVar(&pip, "pip", "pip", "pip executable used to list installed packages")
+	flag.StringVar(&conda, "conda", "conda", "conda executable used with -source conda")
+	flag.StringVar(&python, "python", "", "Python interpreter to run as '<python> -m pip' instead of -pip")
+	flag.StringVar(&pythonVersion, "python-version", "", "Python version, e.g. 3.11, whose standard-library modules are skipped (default: asked from the interpreter)")
+	flag.BoolVar(&noVenv, "no-venv", false, "Do not use the interpreter of a .venv or venv directory in the target")
+	flag.DurationVar(&pipTimeout, "pip-timeout", pyreqs.DefaultPipTimeout, "Maximum time pip or conda may take to list installed packages")
+	flag.IntVar(&pipRetries, "pip-retries", 0, "Times to retry listing installed packages, with exponential backoff, when pip or conda fails to start")
+	flag.StringVar(&freezeFile, "freeze-file", "", "Read the installed packages from saved 'pip freeze' output instead of running pip")
+	flag.BoolVar(&noCache, "no-cache", false, "Always run pip instead of reusing a recent cached package list")
+	flag.IntVar(&jobs, "jobs", 0, "Number of files scanned in parallel (default: number of CPUs)")
+	flag.BoolVar(&verbose, "verbose", false, "Log the imports of every file and each matching decision to stderr (same as -log-level debug)")
+	flag.StringVar(&logLevel, "log-level", "info", "Minimum level of messages logged to stderr: error, warn, info or debug")
+	flag.StringVar(&logFormat, "log-format", "text", "Format of messages logged to stderr: text or json")
+	flag.Var(&ignoreModules, "ignore-module", "Imported module to leave out before matching, e.g. a vendored copy of a PyPI package (repeatable)")
+	flag.Var(&ignorePackages, "ignore-package", "Installed distribution never written as a requirement, whichever module matches it (repeatable)")
+	flag.StringVar(&constraintsFile, "constraints", "", "pip-tools requirements.in file: only pin imported packages declared in it, keeping its version specifiers")
+	flag.Var(&onlyPackages, "only", "Comma-separated distribution names to limit the requirements to (repeatable)")
+	flag.BoolVar(&modulesOnly, "modules-only", false, "Write the sorted third-party modules imported by the project instead of requirements, without running pip or Python")
+	flag.BoolVar(&withDeps, "with-deps", false, "Also include the installed dependencies of each matched package (via pip show)")
+	flag.BoolVar(&keepExtras, "keep-extras", false, "Keep extras such as requests[security] reported by pip freeze instead of dropping them")
+	flag.BoolVar(&stripLocal, "strip-local-version", false, "Drop local version labels such as +cu118 from installed versions (torch==2.1.0+cu118 becomes torch==2.1.0)")
+	flag.BoolVar(&reportUnused, "report-unused", false, "List installed packages that no scanned file imports and no other installed package requires")
+	flag.BoolVar(&generateHashes, "generate-hashes", false, "Append --hash options from PyPI to each exactly pinned requirement (slow)")
+	flag.StringVar(&format, "format", "txt", "Output format: txt, pyproject, json, yaml, setup, setupcfg, pipfile, conda, csv, dot or poetry")
+	flag.StringVar(&templateFile, "template", "", "Go text/template file rendered with the scan report instead of an output format (see examples/templates)")
+	flag.BoolVar(&header, "header", true, "Start requirements.txt files with a generated-by comment (txt format only)")
+	flag.BoolVar(&includeUnresolved, "include-unresolved", false, "List imports without a matching installed package at the end of requirements.txt as '# unresolved: name' comments")
+	flag.BoolVar(&uncommentUnresolved, "unresolved-uncommented", false, "With -include-unresolved, write them as bare 'name  # unresolved' lines that pip will fail on")
+	flag.StringVar(&since, "since", "", "Only rescan files changed since this git ref and update the existing output file (txt format); scans everything outside a git repository")
+	flag.BoolVar(&groupByDir, "group-by-dir", false, "Arrange requirements.txt into '# from dir/' sections by the directories of the importing files")
+	flag.IntVar(&groupDepth, "group-depth", 1, "Directory levels below each target that form a -group-by-dir section")
+	flag.BoolVar(&groupCommon, "group-common", false, "With -group-by-dir, list packages imported from several directories once under '# common'")
+	flag.BoolVar(&merge, "merge", false, "Merge into an existing requirements file, keeping comments, options and hand-written constraints")
+	flag.BoolVar(&cli.failOnUnresolved, "fail-on-unresolved", false, "Exit with status 5 when an import has no matching installed package")
+	flag.BoolVar(&cli.allowEmpty, "allow-empty", false, "Write the output file even when no requirements were found")
+	flag.BoolVar(&cli.quiet, "quiet", false, "Only print errors and warnings (to stderr)")
+	flag.BoolVar(&cli.dryRun, "dry-run", false, "Print the requirements without writing the output file")
+	flag.BoolVar(&cli.check, "check", false, "Compare the output file with the generated requirements and fail if it is stale")
+	flag.BoolVar(&cli.yes, "yes", false, "Overwrite existing output files without asking")
+	flag.BoolVar(&cli.yes, "force", false, "Same as -yes")
+	flag.BoolVar(&cli.noClobber, "no-clobber", false, "Never overwrite existing output files")
+	flag.BoolVar(&cli.watch, "watch", false, "Keep running and regenerate the output whenever a scanned file changes")
+	flag.BoolVar(&cli.perDir, "per-dir", false, "Write a separate output file into every directory with a pyproject.toml, setup.py, setup.cfg or __main__.py, from the imports below it")
 	flag.Parse()
 
-	// Get target directory (default to current directory)
-	targetDir := "."
-	if flag.NArg() > 0 {
-		targetDir = flag.Arg(0)
+	// Get target directories (default to current directory)
+	targetDirs := flag.Args()
+	if len(targetDirs) == 0 {
+		targetDirs = []string{"."}
+	}
+
+	// "-" reads Python code from stdin; the current directory still provides
+	// the config file and virtualenv
+	for _, dir := range targetDirs {
+		if dir == "-" {
+			if len(targetDirs) > 1 {
+				fmt.Fprintf(os.Stderr, "Error: '-' cannot be combined with target directories\n")
+				os.Exit(exitError)
+			}
+			cli.stdin = true
+			targetDirs = []string{"."}
+		}
+	}
+
+	// Fill in values from the config file for flags not given explicitly
+	config := &Config{}
+	if path := findConfig(configFile, targetDirs[0]); path != "" {
+		loaded, err := loadConfig(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		config = loaded
+	}
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	if !explicit["output"] && config.Output != "" {
+		outputFile = config.Output
+	}
+	if !explicit["format"] && config.Format != "" {
+		format = config.Format
+	}
+	if !explicit["pin"] && config.Pin != "" {
+		pinStyle = config.Pin
+	} else if !explicit["pin"] && format == string(pyreqs.FormatPoetry) {
+		// Poetry's own default is a caret requirement
+		pinStyle = string(pyreqs.PinCaret)
+	}
+	excludeDirs = append(config.ExcludeDirs, excludeDirs...)
+	cli.stdout = outputFile == "-" || cli.stdin && outputFile == "" && !cli.check
+	if cli.watch && (cli.stdin || cli.check) {
+		fmt.Fprintf(os.Stderr, "Error: -watch cannot be combined with -check or reading from stdin\n")
+		os.Exit(exitError)
+	}
+	if cli.perDir && (cli.stdin || cli.stdout || cli.watch) {
+		fmt.Fprintf(os.Stderr, "Error: -per-dir cannot be combined with -watch, stdin or stdout\n")
+		os.Exit(exitError)
+	}
+	if cli.stdout && cli.check {
+		fmt.Fprintf(os.Stderr, "Error: -check needs an output file, not stdout\n")
+		os.Exit(exitError)
+	}
+	if cli.stdout {
+		// Keep stdout clean for piping: only the requirements are printed
+		cli.quiet = true
 	}
 
-	generator := &RequirementsGenerator{
-		targetDir:    targetDir,
-		outputFile:   outputFile,
-		foundModules: make(map[string]bool),
+	if !explicit["log-level"] {
+		if verbose {
+			logLevel = "debug"
+		} else if cli.quiet {
+			logLevel = "warn"
+		}
 	}
+	// Only show progress to a person watching; logs and pipes get the summary
+	cli.progress = !cli.quiet && isTerminal(os.Stderr)
 
-	if err := generator.run(); err != nil {
+	level, err := parseLogLevel(logLevel)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	cli.logger, err = newLogger(os.Stderr, level, logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	for _, pattern := range append(append([]string{}, includePatterns...), excludePatterns...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid file pattern '%s': %v\n", pattern, err)
+			os.Exit(exitError)
+		}
 	}
-}
 
-func (rg *RequirementsGenerator) run() error {
-	// Check if target directory exists
-	if _, err := os.Stat(rg.targetDir); os.IsNotExist(err) {
-		return fmt.Errorf("directory '%s' not found", rg.targetDir)
+	parserBackend, err := pyreqs.ParseParserBackend(parser)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	fmt.Printf("Scanning directory '%s' for Python files...\n", rg.targetDir)
+	pin, err := pyreqs.ParsePinStyle(pinStyle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
 
-	// Find and process all Python files
-	if err := rg.findAndProcessPythonFiles(); err != nil {
-		return fmt.Errorf("failed to process Python files: %v", err)
+	order, err := pyreqs.ParseSortOrder(sortOrder)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	// Get installed packages
-	installedPackages, err := rg.getInstalledPackages()
+	packageSource, err := pyreqs.ParseSource(source)
 	if err != nil {
-		return fmt.Errorf("failed to get installed packages: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	// Generate requirements
-	requirements := rg.generateRequirements(installedPackages)
+	var targetPython int
+	if pythonVersion != "" {
+		if targetPython, err = pyreqs.ParsePythonVersion(pythonVersion); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
 
-	// Write to output file
-	if err := rg.writeRequirements(requirements); err != nil {
-		return fmt.Errorf("failed to write requirements: %v", err)
+	outputFormat, err := pyreqs.ParseFormat(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	rg.printResults(requirements)
-	return nil
-}
+	if strings.HasSuffix(outputFile, ".gz") && outputFormat != pyreqs.FormatTxt && outputFormat != pyreqs.FormatJSON && outputFormat != pyreqs.FormatYAML && outputFormat != pyreqs.FormatCSV && outputFormat != pyreqs.FormatDot {
+		fmt.Fprintf(os.Stderr, "Error: gzip-compressed output only applies to -format txt, json, yaml, csv or dot\n")
+		os.Exit(exitError)
+	}
+
+	if cli.check && outputFormat == pyreqs.FormatDot {
+		fmt.Fprintf(os.Stderr, "Error: -check does not support -format dot\n")
+		os.Exit(exitError)
+	}
 
-func (rg *RequirementsGenerator) findAndProcessPythonFiles() error {
-	return filepath.Walk(rg.targetDir, func(path string, info os.FileInfo, err error) error {
+	if outputDir != "" {
+		if cli.stdout || cli.perDir {
+			fmt.Fprintf(os.Stderr, "Error: -output-dir cannot be combined with -per-dir or stdout\n")
+			os.Exit(exitError)
+		}
+		if outputFile == "" {
+			outputFile = pyreqs.DefaultOutputFile(outputFormat)
+		}
+		var err error
+		outputFile, devOutputFile, err = outputDirPaths(outputDir, outputFile, devOutputFile, !cli.dryRun && !cli.check)
 		if err != nil {
-			return err
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	if pipRetries < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -pip-retries cannot be negative\n")
+		os.Exit(exitError)
+	}
+
+	if pin == pyreqs.PinCaret && outputFormat != pyreqs.FormatPoetry {
+		fmt.Fprintf(os.Stderr, "Error: -pin caret only applies to -format poetry\n")
+		os.Exit(exitError)
+	}
+
+	if since != "" && (outputFormat != pyreqs.FormatTxt || devOutputFile != "" || cli.stdin) {
+		fmt.Fprintf(os.Stderr, "Error: -since only applies to -format txt without -dev-output or stdin\n")
+		os.Exit(exitError)
+	}
+
+	if groupByDir && (outputFormat != pyreqs.FormatTxt || merge) {
+		fmt.Fprintf(os.Stderr, "Error: -group-by-dir only applies to -format txt without -merge\n")
+		os.Exit(exitError)
+	}
+
+	if modulesOnly && (outputFormat != pyreqs.FormatTxt || devOutputFile != "" || merge || since != "" || groupByDir || withDeps || generateHashes) {
+		fmt.Fprintf(os.Stderr, "Error: -modules-only only applies to -format txt without -dev-output, -merge, -since, -group-by-dir, -with-deps or -generate-hashes\n")
+		os.Exit(exitError)
+	}
+
+	var outputTemplate *template.Template
+	if templateFile != "" {
+		if outputFormat != pyreqs.FormatTxt || cli.check || merge || since != "" || groupByDir {
+			fmt.Fprintf(os.Stderr, "Error: -template replaces -format and cannot be combined with -check, -merge, -since or -group-by-dir\n")
+			os.Exit(exitError)
+		}
+		if outputTemplate, err = pyreqs.ParseTemplateFile(templateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read template: %v\n", err)
+			os.Exit(exitError)
 		}
+	}
+
+	if reportUnused && modulesOnly {
+		fmt.Fprintf(os.Stderr, "Error: -report-unused needs the installed packages, which -modules-only never lists\n")
+		os.Exit(exitError)
+	}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".py") {
-			if err := rg.extractModulesFromFile(path); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Could not parse %s: %v\n", path, err)
+	if merge && outputFormat != pyreqs.FormatTxt {
+		fmt.Fprintf(os.Stderr, "Error: -merge only applies to -format txt\n")
+		os.Exit(exitError)
+	}
+
+	opts := pyreqs.Options{
+		TargetDirs:          targetDirs,
+		OutputFile:          outputFile,
+		DevOutputFile:       devOutputFile,
+		AllowEmpty:          cli.allowEmpty,
+		Merge:               merge,
+		Header:              header,
+		IncludeUnresolved:   includeUnresolved,
+		UncommentUnresolved: uncommentUnresolved,
+		GroupByDir:          groupByDir,
+		GroupDepth:          groupDepth,
+		GroupCommon:         groupCommon,
+		Version:             version,
+		Command:             commandLine(),
+		Format:              outputFormat,
+		Template:            outputTemplate,
+		ExcludeDirs:         excludeDirs,
+		IncludePatterns:     includePatterns,
+		ExcludePatterns:     excludePatterns,
+		UseGitignore:        useGitignore,
+		FollowSymlinks:      followSymlinks,
+		NoRecursive:         noRecursive || depth == 0,
+		MaxDepth:            depth,
+		IncludeNotebooks:    includeNotebooks,
+		DynamicImports:      dynamicImports,
+		ExcludeTypeChecking: excludeTypeChecking,
+		Parser:              parserBackend,
+		Since:               since,
+		ScanDoctests:        scanDoctests,
+		ScanEntryPoints:     scanEntryPoints,
+		PinStyle:            pin,
+		Sort:                order,
+		Source:              packageSource,
+		Pip:                 pip,
+		Conda:               conda,
+		Python:              python,
+		NoVirtualenv:        noVenv,
+		TargetPythonMinor:   targetPython,
+		NoCache:             noCache,
+		FreezeFile:          freezeFile,
+		PipTimeout:          pipTimeout,
+		PipRetries:          pipRetries,
+		Jobs:                jobs,
+		Logger:              cli.logger,
+		ReportUnused:        reportUnused,
+		GenerateHashes:      generateHashes,
+		KeepExtras:          keepExtras,
+		StripLocalVersion:   stripLocal,
+		ModulesOnly:         modulesOnly,
+		WithDeps:            withDeps,
+		ImportMappings:      config.Mappings,
+		IgnoreModules:       append(config.IgnoreModules, ignoreModules...),
+		IgnorePackages:      append(config.IgnorePackages, ignorePackages...),
+		OnlyPackages:        splitCommaList(onlyPackages),
+		ConstraintsFile:     constraintsFile,
+		Markers:             config.Markers,
+		NamespaceDepths:     config.Namespaces,
+		VersionOverrides:    config.Overrides,
+	}
+
+	if cli.watch {
+		err = watch(opts, cli)
+	} else if cli.perDir {
+		err = runPerDir(opts, cli)
+	} else {
+		err = run(opts, cli)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// splitCommaList splits every comma-separated value of a repeatable flag and
+// drops empty entries.
+func splitCommaList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
 			}
 		}
-		return nil
-	})
+	}
+	return items
+}
+
+// outputDirPaths places the output and dev output files, when set, below
+// dir for -output-dir, creating their directories when create is set.
+// Absolute paths cannot be placed below dir and are an error.
+func outputDirPaths(dir, outputFile, devOutputFile string, create bool) (string, string, error) {
+	paths := []string{outputFile, devOutputFile}
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		if filepath.IsAbs(path) {
+			return "", "", fmt.Errorf("-output-dir cannot be combined with the absolute path '%s'", path)
+		}
+		paths[i] = filepath.Join(dir, path)
+		if create {
+			if err := os.MkdirAll(filepath.Dir(paths[i]), 0o755); err != nil {
+				return "", "", fmt.Errorf("failed to create output directory: %v", err)
+			}
+		}
+	}
+	return paths[0], paths[1], nil
+}
+
+// commandLine returns the invocation of the tool, quoting arguments with
+// spaces so it can be pasted back into a shell.
+func commandLine() string {
+	args := []string{filepath.Base(os.Args[0])}
+	for _, arg := range os.Args[1:] {
+		if strings.ContainsAny(arg, " \t'\"") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
 }
 
-func (rg *RequirementsGenerator) extractModulesFromFile(filePath string) error {
-	content, err := os.ReadFile(filePath)
+func run(opts pyreqs.Options, cli cliOptions) error {
+	if cli.progress && !cli.stdin {
+		opts.Progress = printProgress
+	}
+	generator := pyreqs.NewGenerator(opts)
+	// Warnings raised before a failed scan are reported too
+	defer generator.LogWarnings()
+
+	// Fail before the scan and pip rather than after them
+	if !cli.stdout && !cli.check && !cli.dryRun {
+		for _, path := range []string{generator.OutputFile(), generator.DevOutputFile()} {
+			if err := checkWritable(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cli.stdin {
+		cli.printf("Reading Python code from stdin...\n")
+	} else if dirs := generator.TargetDirs(); len(dirs) == 1 {
+		if info, err := os.Stat(dirs[0]); err == nil && info.Mode().IsRegular() {
+			cli.printf("Scanning file '%s'...\n", dirs[0])
+		} else {
+			cli.printf("Scanning directory '%s' for Python files...\n", dirs[0])
+		}
+	} else {
+		cli.printf("Scanning directories '%s' for Python files...\n", strings.Join(dirs, "', '"))
+	}
+
+	if venv := generator.Virtualenv(); venv != "" {
+		cli.printf("Using packages from virtual environment '%s'\n", venv)
+	}
+
+	var requirements []string
+	var err error
+	if cli.stdin {
+		requirements, err = generator.ScanReader(os.Stdin)
+	} else {
+		requirements, err = generator.Scan()
+		if opts.Progress != nil {
+			// Clear the progress line so later output starts on a fresh line
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	// Parse Python imports using regex (since we're in Go, we can't use Python's ast)
-	imports := rg.extractImportsFromPythonCode(string(content))
-	
-	for _, module := range imports {
-		rg.foundModules[module] = true
+	if cli.stdout {
+		if opts.Template != nil {
+			err = generator.WriteTemplate(os.Stdout)
+		} else {
+			err = writeStdout(generator, opts.Format)
+		}
+		if err != nil {
+			return err
+		}
+		return checkUnresolved(generator.Unresolved(), cli)
 	}
 
-	return nil
-}
-
-func (rg *RequirementsGenerator) extractImportsFromPythonCode(content string) []string {
-	var modules []string
-	
-	// Regex patterns for Python imports
-	importRegex := regexp.MustCompile(`(?m)^import\s+([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`)
-	fromImportRegex := regexp.MustCompile(`(?m)^from\s+([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)\s+import`)
-	
-	// Find "import module" statements
-	matches := importRegex.FindAllStringSubmatch(content, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			// Get top-level module (e.g., "requests" from "requests.auth")
-			topLevel := strings.Split(match[1], ".")[0]
-			modules = append(modules, topLevel)
+	if cli.check {
+		if err := checkRequirements(generator, generator.OutputFile(), cli); err != nil {
+			return err
 		}
+		return checkUnresolved(generator.Unresolved(), cli)
 	}
-	
-	// Find "from module import" statements
-	matches = fromImportRegex.FindAllStringSubmatch(content, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			// Get top-level module
-			topLevel := strings.Split(match[1], ".")[0]
-			modules = append(modules, topLevel)
+
+	// Write to output file unless this is only a preview
+	if !cli.dryRun {
+		overwrite, err := confirmOverwrite(generator, opts.Merge, cli)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			cli.printf("Nothing was written.\n")
+			return nil
+		}
+
+		if err := generator.Write(); err != nil {
+			return fmt.Errorf("failed to write requirements: %v", err)
 		}
 	}
-	
-	return modules
+
+	printResults(generator.OutputFile(), requirements, generator.Unresolved(), cli)
+	printUnused(generator.Unused(), cli)
+	printStats(generator.Stats(), cli)
+	if version := generator.PythonVersion(); version != "" {
+		cli.printf("# Requires Python >=%s (inferred from syntax and standard-library imports)\n", version)
+	}
+	if devOutputFile := generator.DevOutputFile(); devOutputFile != "" {
+		printDevResults(devOutputFile, generator.DevRequirements(), cli)
+	}
+	return checkUnresolved(generator.Unresolved(), cli)
 }
 
-func (rg *RequirementsGenerator) getInstalledPackages() (map[string]string, error) {
-	cmd := exec.Command("pip", "freeze")
-	output, err := cmd.Output()
+// checkWritable reports an error when the output file at path could not be
+// written: an existing file must open for writing, and a new one must be
+// creatable in its directory. An empty path is not checked.
+func checkWritable(path string) error {
+	if path == "" {
+		return nil
+	}
+	if file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0); err == nil {
+		return file.Close()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("cannot write output file '%s': %v", path, err)
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(path), ".pyreqs-*")
 	if err != nil {
-		return nil, fmt.Errorf("failed to run 'pip freeze': %v", err)
-	}
-
-	packages := make(map[string]string)
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.Contains(line, "==") {
-			parts := strings.Split(line, "==")
-			if len(parts) >= 2 {
-				name := strings.ToLower(parts[0])
-				packages[name] = line
-			}
-		}
+		return fmt.Errorf("cannot create output file '%s': %v", path, err)
 	}
-	
-	return packages, scanner.Err()
+	probe.Close()
+	return os.Remove(probe.Name())
 }
 
-func (rg *RequirementsGenerator) generateRequirements(installedPackages map[string]string) []string {
-	var requirements []string
-	normalizedFound := make(map[string]bool)
-	
-	// Normalize found module names
-	for module := range rg.foundModules {
-		normalized := strings.ToLower(strings.ReplaceAll(module, "-", "_"))
-		normalizedFound[normalized] = true
+// confirmOverwrite reports whether the output files that would be written
+// may replace existing files. Without -yes it asks on a terminal, defaulting
+// to no; non-interactive runs overwrite unless -no-clobber is set, which is
+// an error. Merging keeps the existing contents, so it is never asked about.
+func confirmOverwrite(generator *pyreqs.Generator, merge bool, cli cliOptions) (bool, error) {
+	var existing []string
+	if len(generator.Requirements()) > 0 || cli.allowEmpty {
+		existing = append(existing, generator.OutputFile())
 	}
-	
-	// Match installed packages with found modules
-	var packageNames []string
-	for pkgName := range installedPackages {
-		packageNames = append(packageNames, pkgName)
+	if devOutputFile := generator.DevOutputFile(); devOutputFile != "" && (len(generator.DevRequirements()) > 0 || cli.allowEmpty) {
+		existing = append(existing, devOutputFile)
 	}
-	sort.Strings(packageNames) // Sort for consistent output
-	
-	for _, pkgName := range packageNames {
-		normalizedPkg := strings.ToLower(strings.ReplaceAll(pkgName, "-", "_"))
-		if normalizedFound[normalizedPkg] {
-			requirements = append(requirements, installedPackages[pkgName])
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, path := range existing {
+		if _, err := os.Stat(path); err != nil {
+			continue
 		}
+		switch {
+		case cli.noClobber:
+			return false, fmt.Errorf("'%s' already exists (-no-clobber)", path)
+		case cli.yes || merge || !isTerminal(os.Stdin) || !isTerminal(os.Stdout):
+			continue
+		}
+		fmt.Printf("Overwrite %s? [y/N] ", path)
+		answer, _ := reader.ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkUnresolved returns an error listing the unresolved imports when
+// -fail-on-unresolved is set.
+func checkUnresolved(unresolved []string, cli cliOptions) error {
+	if !cli.failOnUnresolved || len(unresolved) == 0 {
+		return nil
 	}
-	
-	return requirements
+	return &unresolvedError{modules: unresolved}
 }
 
-func (rg *RequirementsGenerator) writeRequirements(requirements []string) error {
-	file, err := os.Create(rg.outputFile)
+// writeStdout prints the requirements to stdout in the txt, json, yaml, csv or dot format.
+func writeStdout(generator *pyreqs.Generator, format pyreqs.Format) error {
+	switch format {
+	case pyreqs.FormatTxt, "":
+		return generator.WriteRequirements(os.Stdout)
+	case pyreqs.FormatJSON:
+		return generator.WriteReport(os.Stdout)
+	case pyreqs.FormatYAML:
+		return generator.WriteYAML(os.Stdout)
+	case pyreqs.FormatCSV:
+		return generator.WriteCSV(os.Stdout)
+	case pyreqs.FormatDot:
+		return generator.WriteDot(os.Stdout)
+	default:
+		return fmt.Errorf("format '%s' cannot be written to stdout (want txt, json, yaml, csv or dot)", format)
+	}
+}
+
+// checkRequirements prints how the output file differs from the generated
+// requirements and returns an error when it is out of date.
+func checkRequirements(generator *pyreqs.Generator, outputFile string, cli cliOptions) error {
+	added, removed, changed, err := generator.Check()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to read '%s': %v", outputFile, err)
+	}
+
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		cli.printf("'%s' is up to date.\n", outputFile)
+		return nil
+	}
+
+	for _, req := range added {
+		fmt.Printf("+ %s\n", req)
 	}
-	defer file.Close()
-	
-	writer := bufio.NewWriter(file)
-	for _, req := range requirements {
-		fmt.Fprintln(writer, req)
+	for _, req := range removed {
+		fmt.Printf("- %s\n", req)
 	}
-	
-	return writer.Flush()
+	for _, req := range changed {
+		fmt.Printf("~ %s\n", req)
+	}
+
+	return &outOfDateError{outputFile: outputFile}
 }
 
-func (rg *RequirementsGenerator) printResults(requirements []string) {
+func printResults(outputFile string, requirements, unresolved []string, cli cliOptions) {
 	if len(requirements) > 0 {
-		fmt.Printf("Successfully generated '%s' with detected Python modules and their versions.\n", rg.outputFile)
-		fmt.Printf("Contents of '%s':\n", rg.outputFile)
+		if cli.dryRun {
+			cli.printf("Dry run: '%s' was not written.\n", outputFile)
+			cli.printf("Contents that would be written to '%s':\n", outputFile)
+		} else {
+			cli.printf("Successfully generated '%s' with detected Python modules and their versions.\n", outputFile)
+			cli.printf("Contents of '%s':\n", outputFile)
+		}
 		for _, req := range requirements {
-			fmt.Println(req)
+			cli.printf("%s\n", req)
+		}
+	} else {
+		cli.printf("No external Python modules with installed versions were found.\n")
+		if !cli.dryRun && !cli.allowEmpty {
+			cli.printf("'%s' was left untouched (use -allow-empty to write it anyway).\n", outputFile)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		cli.printf("Unresolved imports (no matching installed package):\n")
+		for _, module := range unresolved {
+			cli.printf("  %s\n", module)
+		}
+	}
+}
+
+// printUnused lists the installed packages reported by -report-unused.
+func printUnused(unused []string, cli cliOptions) {
+	if len(unused) == 0 {
+		return
+	}
+	cli.printf("Installed packages not imported by the scanned code (candidates for removal):\n")
+	for _, pkgName := range unused {
+		cli.printf("  %s\n", pkgName)
+	}
+}
+
+func printStats(stats pyreqs.Stats, cli cliOptions) {
+	cli.logger.Info("scan finished",
+		"files", stats.FilesScanned, "imports", stats.ImportsFound, "elapsed", stats.Elapsed.Round(time.Millisecond),
+		"stdlib", stats.Stdlib, "local", stats.Local, "ignored", stats.Ignored, "matched", stats.Matched,
+		"unresolved", stats.Unresolved, "requirements", stats.Requirements)
+}
+
+func printDevResults(devOutputFile string, devRequirements []string, cli cliOptions) {
+	if len(devRequirements) == 0 {
+		cli.printf("No dev requirements were found for '%s'.\n", devOutputFile)
+		if !cli.dryRun && !cli.allowEmpty {
+			cli.printf("'%s' was left untouched (use -allow-empty to write it anyway).\n", devOutputFile)
 		}
+		return
+	}
+	if cli.dryRun {
+		cli.printf("Contents that would be written to '%s':\n", devOutputFile)
 	} else {
-		fmt.Println("No external Python modules with installed versions were found.")
+		cli.printf("Contents of '%s':\n", devOutputFile)
+	}
+	for _, req := range devRequirements {
+		cli.printf("%s\n", req)
 	}
-}
\ No newline at end of file
+}
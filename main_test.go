@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputDirPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "build", "nested")
+
+	// Nothing is created for -dry-run and -check
+	outputFile, devOutputFile, err := outputDirPaths(dir, "requirements.txt", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "requirements.txt"); outputFile != want || devOutputFile != "" {
+		t.Errorf("outputDirPaths = %q, %q, want %q, \"\"", outputFile, devOutputFile, want)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("output directory created without create: %v", err)
+	}
+
+	outputFile, devOutputFile, err = outputDirPaths(dir, "requirements.txt", filepath.Join("dev", "requirements-dev.txt"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "dev", "requirements-dev.txt"); devOutputFile != want {
+		t.Errorf("dev output file = %q, want %q", devOutputFile, want)
+	}
+	for _, path := range []string{outputFile, devOutputFile} {
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			t.Errorf("directory of %s not created: %v", path, err)
+		}
+	}
+
+	// The requirements are written into the new directory
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"app.py": "import requests\n"})
+	opts, cli := testOptions(t, "requests==2.31.0\n", src)
+	opts.OutputFile = outputFile
+	if err := run(opts, cli); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "requirements.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "requests==2.31.0\n"; string(content) != want {
+		t.Errorf("requirements.txt = %q, want %q", content, want)
+	}
+}
+
+func TestOutputDirPathsAbsolute(t *testing.T) {
+	dir := t.TempDir()
+	absolute := filepath.Join(dir, "requirements.txt")
+	if _, _, err := outputDirPaths(filepath.Join(dir, "build"), absolute, "", true); err == nil {
+		t.Errorf("outputDirPaths with the absolute path %q succeeded, want an error", absolute)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "build")); !os.IsNotExist(err) {
+		t.Errorf("output directory created for a rejected path: %v", err)
+	}
+}
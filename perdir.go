@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaamiriOuail/go-pyreqs/pyreqs"
+)
+
+// serviceMarkers are the files that make a directory the root of a service
+// for -per-dir.
+var serviceMarkers = []string{"pyproject.toml", "setup.py", "setup.cfg", "__main__.py"}
+
+// runPerDir runs run once for every service root below the target
+// directories, writing each root's requirements into that root. Files of a
+// nested root only count towards the nested one. Every root is attempted;
+// the first error is returned.
+func runPerDir(opts pyreqs.Options, cli cliOptions) error {
+	excluded := make(map[string]bool)
+	for _, dir := range append(append([]string{}, pyreqs.DefaultExcludedDirs...), opts.ExcludeDirs...) {
+		excluded[dir] = true
+	}
+
+	var roots []string
+	for _, dir := range opts.TargetDirs {
+		found, err := findServiceRoots(dir, excluded)
+		if err != nil {
+			return fmt.Errorf("failed to find service roots in '%s': %v", dir, err)
+		}
+		roots = append(roots, found...)
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("no service roots found: no directory contains %s", strings.Join(serviceMarkers, ", "))
+	}
+
+	outputName := filepath.Base(pyreqs.NewGenerator(opts).OutputFile())
+	var firstErr error
+	for i, root := range roots {
+		if i > 0 {
+			cli.printf("\n")
+		}
+		rootOpts := opts
+		rootOpts.TargetDirs = []string{root}
+		rootOpts.OutputFile = filepath.Join(root, outputName)
+		if opts.DevOutputFile != "" {
+			rootOpts.DevOutputFile = filepath.Join(root, filepath.Base(opts.DevOutputFile))
+		}
+		rootOpts.ExcludePaths = append(append([]string{}, opts.ExcludePaths...), nestedRoots(root, roots)...)
+
+		if err := run(rootOpts, cli); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", root, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// findServiceRoots returns dir and the directories below it, except
+// excluded ones, that contain one of serviceMarkers, in walk order.
+func findServiceRoots(dir string, excluded map[string]bool) ([]string, error) {
+	var roots []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if path != dir && excluded[info.Name()] {
+			return filepath.SkipDir
+		}
+		for _, marker := range serviceMarkers {
+			if _, err := os.Stat(filepath.Join(path, marker)); err == nil {
+				roots = append(roots, path)
+				break
+			}
+		}
+		return nil
+	})
+	return roots, err
+}
+
+// nestedRoots returns the roots that lie below root.
+func nestedRoots(root string, roots []string) []string {
+	var nested []string
+	for _, other := range roots {
+		if rel, err := filepath.Rel(root, other); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			nested = append(nested, other)
+		}
+	}
+	return nested
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaamiriOuail/go-pyreqs/pyreqs"
+)
+
+// writeTree creates files, keyed by slash-separated path below dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testOptions returns Options that scan dirs against the packages in freeze
+// without running pip, and cliOptions that print and ask nothing.
+func testOptions(t *testing.T, freeze string, dirs ...string) (pyreqs.Options, cliOptions) {
+	t.Helper()
+	freezeFile := filepath.Join(t.TempDir(), "freeze.txt")
+	if err := os.WriteFile(freezeFile, []byte(freeze), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	opts := pyreqs.Options{
+		TargetDirs:        dirs,
+		FreezeFile:        freezeFile,
+		NoVirtualenv:      true,
+		TargetPythonMinor: 11,
+		Logger:            logger,
+	}
+	return opts, cliOptions{yes: true, quiet: true, logger: logger}
+}
+
+func TestRunPerDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"services/api/pyproject.toml":       "[project]\nname = \"api\"\n",
+		"services/api/app.py":               "import flask\n",
+		"services/api/worker/__main__.py":   "import requests\n",
+		"services/api/worker/tasks/send.py": "import six\n",
+		"README.md":                         "not a service\n",
+	})
+	opts, cli := testOptions(t, "Flask==2.3.2\nrequests==2.31.0\nsix==1.16.0\n", dir)
+
+	if err := runPerDir(opts, cli); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"services/api/requirements.txt":        "Flask==2.3.2\n",
+		"services/api/worker/requirements.txt": "requests==2.31.0\nsix==1.16.0\n",
+	}
+	for name, want := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, content, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "requirements.txt")); !os.IsNotExist(err) {
+		t.Errorf("requirements.txt written outside the service roots: %v", err)
+	}
+}
+
+func TestRunPerDirNoRoots(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app.py": "import requests\n"})
+	opts, cli := testOptions(t, "requests==2.31.0\n", dir)
+	if err := runPerDir(opts, cli); err == nil {
+		t.Error("runPerDir without service roots succeeded, want an error")
+	}
+}
@@ -0,0 +1,115 @@
+package pyreqs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// packageCacheTTL bounds how long a cached package list is trusted, covering
+// installs that do not touch the directories checked by packageCacheKey.
+const packageCacheTTL = 10 * time.Minute
+
+// packageCache is the on-disk form of a cached package list. Duplicates
+// keeps the duplicate lines dropped from the list, so a cached run warns
+// about them like the run that listed the packages.
+type packageCache struct {
+	Created    time.Time          `json:"created"`
+	Packages   map[string]string  `json:"packages"`
+	Duplicates []duplicatePackage `json:"duplicates,omitempty"`
+}
+
+// packageCacheKey identifies the package list of the pip or conda command
+// that would run, from the resolved executable, its arguments and the
+// modification times of the executable and the site-packages and conda-meta
+// directories next to it. Installing or removing a package changes those
+// directories and so the key.
+func (g *Generator) packageCacheKey() (string, bool) {
+	var cmd *exec.Cmd
+	var err error
+	if g.source == SourceConda {
+		cmd, err = g.condaCommand(context.Background(), "list", "--export")
+	} else {
+		cmd, err = g.pipCommand(context.Background(), string(g.source))
+	}
+	if err != nil {
+		return "", false
+	}
+
+	executable, err := filepath.EvalSymlinks(cmd.Path)
+	if err != nil {
+		return "", false
+	}
+	parts := append([]string{executable}, cmd.Args[1:]...)
+
+	prefix := filepath.Dir(filepath.Dir(executable))
+	sitePackages, _ := filepath.Glob(filepath.Join(prefix, "lib", "python*", "site-packages"))
+	sitePackages = append(sitePackages, filepath.Join(prefix, "Lib", "site-packages"), filepath.Join(prefix, "conda-meta"))
+	if env := os.Getenv("CONDA_PREFIX"); env != "" && g.source == SourceConda {
+		// conda lists the active environment, not the one it is installed in
+		parts = append(parts, env)
+		sitePackages = append(sitePackages, filepath.Join(env, "conda-meta"))
+	}
+	for _, path := range append([]string{executable}, sitePackages...) {
+		if info, err := os.Stat(path); err == nil {
+			parts = append(parts, fmt.Sprintf("%s@%d", path, info.ModTime().UnixNano()))
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]), true
+}
+
+func packageCachePath(key string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-pyreqs", key+".json"), nil
+}
+
+// readPackageCache returns the cached package list for key if it is fresh.
+func readPackageCache(key string) (packageCache, bool) {
+	path, err := packageCachePath(key)
+	if err != nil {
+		return packageCache{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return packageCache{}, false
+	}
+
+	var cache packageCache
+	if err := json.Unmarshal(data, &cache); err != nil || time.Since(cache.Created) > packageCacheTTL {
+		return packageCache{}, false
+	}
+	return cache, true
+}
+
+// writePackageCache stores cache for key, stamped with the current time.
+// Failures are ignored since the cache is only an optimization.
+func writePackageCache(key string, cache packageCache) {
+	path, err := packageCachePath(key)
+	if err != nil {
+		return
+	}
+
+	cache.Created = time.Now()
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
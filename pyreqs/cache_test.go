@@ -0,0 +1,46 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPackageCacheKeepsDuplicateWarnings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pip")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	runs := filepath.Join(dir, "runs")
+	pip := filepath.Join(dir, "pip")
+	writeCommand(t, pip, "#!/bin/sh\necho run >> "+runs+"\nprintf 'PyYAML==6.0\\npyyaml==5.4\\nsix==1.16.0\\n'\n")
+
+	want := map[string]string{"pyyaml": "PyYAML==6.0", "six": "six==1.16.0"}
+	wantWarnings := []string{"package listed more than once by pip freeze, keeping the first: kept=PyYAML==6.0 ignored=pyyaml==5.4"}
+	for i := 0; i < 2; i++ {
+		g := newTestGenerator(Options{Pip: pip, NoVirtualenv: true})
+		packages, err := g.getInstalledPackages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(packages, want) {
+			t.Errorf("run %d: packages = %v, want %v", i+1, packages, want)
+		}
+		if !reflect.DeepEqual(g.Warnings(), wantWarnings) {
+			t.Errorf("run %d: warnings = %q, want %q", i+1, g.Warnings(), wantWarnings)
+		}
+	}
+
+	// The second run is served from the cache
+	content, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(content), "run"); n != 1 {
+		t.Errorf("pip ran %d times, want 1", n)
+	}
+}
@@ -0,0 +1,190 @@
+package pyreqs
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Check compares the requirement lines produced by the last Scan with the
+// current contents of the output file, and the dev requirements with the dev
+// output file when configured. A missing output file counts as empty.
+func (g *Generator) Check() (added, removed, changed []string, err error) {
+	var existing []string
+	switch g.format {
+	case FormatPyproject:
+		existing, err = readPyprojectDependencies(g.outputFile)
+	case FormatJSON:
+		existing, err = readJSONRequirements(g.outputFile)
+	case FormatYAML:
+		existing, err = readYAMLRequirements(g.outputFile)
+	case FormatSetup:
+		existing, err = readSetupPyRequires(g.outputFile)
+	case FormatSetupCfg:
+		existing, err = readSetupCfgRequires(g.outputFile)
+	case FormatPipfile:
+		existing, err = readPipfilePackages(g.outputFile)
+	case FormatPoetry:
+		existing, err = readPoetryDependencies(g.outputFile)
+	case FormatConda:
+		existing, err = readCondaDependencies(g.outputFile)
+	case FormatCSV:
+		existing, err = readCSVRequirements(g.outputFile)
+	default:
+		existing, err = readRequirementsFile(g.outputFile)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, nil, err
+	}
+
+	generated := g.requirements
+	switch g.format {
+	case FormatConda:
+		if generated, err = expectedCondaDependencies(g.outputFile, g.requirements); err != nil {
+			return nil, nil, nil, err
+		}
+	case FormatPyproject, FormatSetup, FormatSetupCfg, FormatPoetry:
+		generated = pep508Requirements(g.requirements)
+	case FormatCSV:
+		generated = nil
+		for _, req := range g.Report().Requirements {
+			generated = append(generated, csvRequirement(req.Name, req.Version))
+		}
+	case FormatTxt:
+		if generated, err = g.expectedRequirements(g.outputFile, g.requirements); err != nil {
+			return nil, nil, nil, err
+		}
+		if g.includeUnresolved && g.uncommentUnresolved {
+			generated = append(generated, g.unresolved...)
+		}
+	}
+	added, removed, changed = g.diff(existing, generated)
+
+	if g.devOutputFile != "" {
+		existingDev, err := readRequirementsFile(g.devOutputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, nil, nil, err
+		}
+		expectedDev, err := g.expectedRequirements(g.devOutputFile, g.devRequirements)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		devAdded, devRemoved, devChanged := g.diff(existingDev, expectedDev)
+		added = append(added, devAdded...)
+		removed = append(removed, devRemoved...)
+		changed = append(changed, devChanged...)
+	}
+	return added, removed, changed, nil
+}
+
+// expectedRequirements returns the requirement lines a requirements file at
+// path should hold, taking merging with its current contents into account.
+func (g *Generator) expectedRequirements(path string, requirements []string) ([]string, error) {
+	if !g.merge {
+		return requirements, nil
+	}
+	merged, err := mergedRequirements(path, requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range merged {
+		trimmed := strings.TrimSpace(line)
+		if i := strings.Index(trimmed, " #"); i >= 0 {
+			trimmed = strings.TrimSpace(trimmed[:i])
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines, nil
+}
+
+// diff reports the generated entries missing from existing, the existing
+// entries no longer generated, and the entries whose specifier differs,
+// formatted as "old -> new". Entries are matched by their PEP 503 name, so
+// "Flask==2.3.2" and "flask==2.3.2" are the same entry.
+func (g *Generator) diff(existing, generated []string) (added, removed, changed []string) {
+	existingByName := make(map[string]string)
+	for _, line := range existing {
+		existingByName[requirementName(line)] = line
+	}
+
+	generatedByName := make(map[string]string)
+	for _, line := range generated {
+		name := requirementName(line)
+		generatedByName[name] = line
+
+		old, ok := existingByName[name]
+		switch {
+		case !ok:
+			added = append(added, line)
+		case specifierOf(old) != specifierOf(line):
+			changed = append(changed, old+" -> "+line)
+		}
+	}
+
+	for _, line := range existing {
+		if _, ok := generatedByName[requirementName(line)]; !ok {
+			removed = append(removed, line)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// readRequirementsFile returns the requirement lines of a requirements file,
+// joining continued lines and skipping blank lines, comments and options.
+func readRequirementsFile(path string) ([]string, error) {
+	content, err := readOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	var continued string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := continued + strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "\\") {
+			continued = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		continued = ""
+
+		// Drop inline comments and per-requirement options such as --hash
+		if comment := strings.Index(line, " #"); comment >= 0 {
+			line = strings.TrimSpace(line[:comment])
+		}
+		if option := strings.Index(line, " --"); option >= 0 {
+			line = strings.TrimSpace(line[:option])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// requirementName returns the normalized distribution name of a requirement
+// line such as "requests==2.31.0" or "numpy>=1.24 ; python_version > '3.8'".
+func requirementName(line string) string {
+	return pep503Normalize(requirementDistribution(line))
+}
+
+// specifierOf returns what a requirement line asks for besides its name,
+// e.g. "==2.3.2" for "Flask==2.3.2". Direct references are returned whole.
+func specifierOf(line string) string {
+	if _, _, _, ok := directReference(line); ok {
+		return line
+	}
+	return line[len(requirementDistribution(line)):]
+}
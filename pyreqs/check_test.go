@@ -0,0 +1,71 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name                    string
+		existing, generated     []string
+		added, removed, changed []string
+	}{
+		{
+			name:      "up to date",
+			existing:  []string{"requests==2.31.0", "six==1.16.0"},
+			generated: []string{"six==1.16.0", "requests==2.31.0"},
+		},
+		{
+			name:      "added",
+			existing:  []string{"requests==2.31.0"},
+			generated: []string{"requests==2.31.0", "numpy==1.24.3", "flask==2.3.2"},
+			added:     []string{"flask==2.3.2", "numpy==1.24.3"},
+		},
+		{
+			name:      "removed",
+			existing:  []string{"requests==2.31.0", "numpy==1.24.3"},
+			generated: []string{"requests==2.31.0"},
+			removed:   []string{"numpy==1.24.3"},
+		},
+		{
+			name:      "version changed",
+			existing:  []string{"requests==2.28.0", `pywin32==305 ; sys_platform == "win32"`},
+			generated: []string{"requests==2.31.0", `pywin32==306 ; sys_platform == "win32"`},
+			changed:   []string{`pywin32==305 ; sys_platform == "win32" -> pywin32==306 ; sys_platform == "win32"`, "requests==2.28.0 -> requests==2.31.0"},
+		},
+		{
+			name:      "specifier changed",
+			existing:  []string{"requests==2.31.0"},
+			generated: []string{"requests>=2.31.0"},
+			changed:   []string{"requests==2.31.0 -> requests>=2.31.0"},
+		},
+		{
+			name:      "case and PEP 503 spelling",
+			existing:  []string{"flask==2.3.2", "typing_extensions==4.7.1", "Zope.Interface==6.0"},
+			generated: []string{"Flask==2.3.2", "typing-extensions==4.7.1", "zope-interface==6.0"},
+		},
+		{
+			name:      "all at once",
+			existing:  []string{"Flask==2.3.2", "six==1.16.0", "requests==2.28.0"},
+			generated: []string{"flask==2.3.2", "requests==2.31.0", "numpy==1.24.3"},
+			added:     []string{"numpy==1.24.3"},
+			removed:   []string{"six==1.16.0"},
+			changed:   []string{"requests==2.28.0 -> requests==2.31.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed, changed := newTestGenerator(Options{}).diff(tt.existing, tt.generated)
+			if !reflect.DeepEqual(added, tt.added) {
+				t.Errorf("added = %q, want %q", added, tt.added)
+			}
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("removed = %q, want %q", removed, tt.removed)
+			}
+			if !reflect.DeepEqual(changed, tt.changed) {
+				t.Errorf("changed = %q, want %q", changed, tt.changed)
+			}
+		})
+	}
+}
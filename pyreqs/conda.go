@@ -0,0 +1,261 @@
+package pyreqs
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// condaCommand builds a conda invocation that is killed when ctx is done.
+func (g *Generator) condaCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	path, err := exec.LookPath(g.conda)
+	if err != nil {
+		return nil, &ExecutableNotFoundError{Kind: "conda executable", Name: g.conda}
+	}
+	return exec.CommandContext(ctx, path, args...), nil
+}
+
+// getInstalledPackagesFromConda parses the "name=version=build" lines of
+// "conda list --export" into "name==version" lines.
+func (g *Generator) getInstalledPackagesFromConda() (map[string]string, error) {
+	output, err := g.runCommand("conda list", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.condaCommand(ctx, "list", "--export")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "=")
+		if len(parts) < 2 {
+			continue
+		}
+		packages[strings.ToLower(parts[0])] = parts[0] + "==" + parts[1]
+	}
+
+	return packages, scanner.Err()
+}
+
+// condaDependency converts a requirement line into a conda match spec:
+// "==" pins become conda's "=", and environment markers, which conda does
+// not understand, are dropped.
+func condaDependency(line string) string {
+	req, _ := splitMarker(line)
+	return strings.Replace(req, "==", "=", 1)
+}
+
+// writeCondaEnvironment replaces the dependencies list of an environment.yml,
+// keeping the name, channels and any other keys. Nested entries of the list,
+// such as a "- pip:" section, are kept after the conda dependencies; the
+// requirements already listed under "- pip:" are updated there instead. A
+// new file is named after the first target directory.
+func (g *Generator) writeCondaEnvironment(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if os.IsNotExist(err) {
+		content = []byte(g.defaultCondaEnvironment())
+	} else if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	start, end := yamlKeyRange(lines, "dependencies")
+	var current []string
+	if start >= 0 {
+		current = lines[start+1 : end]
+	}
+	body := append([]string{"dependencies:"}, condaDependencyLines(current, requirements)...)
+
+	if start < 0 {
+		// Append the list, keeping a single trailing newline
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(append(lines, body...), "")
+	} else {
+		lines = append(append(append([]string{}, lines[:start]...), body...), lines[end:]...)
+	}
+
+	return os.WriteFile(g.outputFile, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// condaDependencyLines returns the lines of the dependencies list written for
+// requirements in place of current, the lines of the existing list without
+// its key: the match specs of the requirements not listed under "- pip:",
+// then the nested entries of current (see condaNestedEntries), with pip
+// itself added to the specs when there is a "- pip:" section.
+func condaDependencyLines(current, requirements []string) []string {
+	pipInstalled := make(map[string]bool)
+	nested, hasPip := condaNestedEntries(current, requirements, pipInstalled)
+
+	var lines []string
+	for _, req := range requirements {
+		if requirementName(req) == "pip" {
+			hasPip = false
+		}
+		if !pipInstalled[requirementName(req)] {
+			lines = append(lines, "  - "+condaDependency(req))
+		}
+	}
+	// conda needs pip itself to install a "- pip:" section
+	if hasPip {
+		lines = append(lines, "  - pip")
+	}
+	return append(lines, nested...)
+}
+
+// condaNestedEntries returns the lines of the entries of a dependencies list,
+// given without its key line, that are mappings rather than match specs, such
+// as "- pip:" with its own list, in order. The items of a pip list that name
+// one of requirements are merged with it like a requirements.txt line (see
+// mergeRequirement), and its name is added to pipInstalled. hasPip reports
+// whether there is a "- pip:" section.
+func condaNestedEntries(lines, requirements []string, pipInstalled map[string]bool) (nested []string, hasPip bool) {
+	byName := make(map[string]string, len(requirements))
+	for _, req := range requirements {
+		byName[requirementName(req)] = req
+	}
+
+	itemIndent := -1
+	inNested, inPip := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if strings.HasPrefix(trimmed, "- ") && (itemIndent < 0 || indent <= itemIndent) {
+			if itemIndent < 0 {
+				itemIndent = indent
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+			inNested = strings.HasSuffix(value, ":")
+			inPip = value == "pip:"
+			hasPip = hasPip || inPip
+		}
+		if !inNested {
+			continue
+		}
+		if inPip && indent > itemIndent && strings.HasPrefix(trimmed, "- ") {
+			spec := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+			if req, ok := byName[requirementName(spec)]; ok {
+				pipInstalled[requirementName(req)] = true
+				line = line[:indent] + "- " + mergeRequirement(spec, req)
+			}
+		}
+		nested = append(nested, line)
+	}
+	return nested, hasPip
+}
+
+func (g *Generator) defaultCondaEnvironment() string {
+	name := filepath.Base(g.targetDirs[0])
+	if absolute, err := filepath.Abs(g.targetDirs[0]); err == nil {
+		name = filepath.Base(absolute)
+	}
+	return "name: " + name + "\nchannels:\n  - defaults\n"
+}
+
+// readCondaDependencies returns the dependencies of an environment.yml: its
+// conda match specs, then the requirements of its "- pip:" section. Other
+// nested lists are skipped.
+func readCondaDependencies(path string) ([]string, error) {
+	current, err := readCondaList(path)
+	if err != nil {
+		return nil, err
+	}
+	return condaSpecs(current), nil
+}
+
+// expectedCondaDependencies returns the dependencies readCondaDependencies
+// reads once requirements are written to the environment.yml at path, which
+// may not exist yet.
+func expectedCondaDependencies(path string, requirements []string) ([]string, error) {
+	current, err := readCondaList(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return condaSpecs(condaDependencyLines(current, requirements)), nil
+}
+
+// readCondaList returns the lines of the dependencies list of the
+// environment.yml at path, without its key line.
+func readCondaList(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	start, end := yamlKeyRange(lines, "dependencies")
+	if start < 0 {
+		return nil, nil
+	}
+	return lines[start+1 : end], nil
+}
+
+// condaSpecs returns the match specs listed by the lines of a dependencies
+// list, followed by the items of its "- pip:" section.
+func condaSpecs(lines []string) []string {
+	var specs, pip []string
+	itemIndent := -1
+	inPip := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if itemIndent < 0 {
+			itemIndent = indent
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		value = strings.Trim(value, `"'`)
+		switch {
+		case indent <= itemIndent:
+			inPip = value == "pip:"
+			if !strings.HasSuffix(value, ":") {
+				specs = append(specs, value)
+			}
+		case inPip:
+			pip = append(pip, value)
+		}
+	}
+	return append(specs, pip...)
+}
+
+// yamlKeyRange returns the line range of a top-level YAML key, from the key
+// line to the next top-level key, or -1, -1 when the key is missing. Blank
+// lines and comments just before the next key are left outside the range.
+func yamlKeyRange(lines []string, key string) (start, end int) {
+	start = -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, key+":") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+
+	end = len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '#' && line[0] != '-' {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && (strings.TrimSpace(lines[end-1]) == "" || strings.HasPrefix(lines[end-1], "#")) {
+		end--
+	}
+	return start, end
+}
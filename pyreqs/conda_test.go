@@ -0,0 +1,112 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCondaEnvironmentKeepsPipSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environment.yml")
+	existing := `name: service
+channels:
+  - conda-forge
+dependencies:
+  - python=3.11
+  - numpy=1.20.0
+  - pip
+  - pip:
+      # installed from PyPI
+      - requests==2.28.0  # keep on 2.x
+      - --index-url https://pypi.example.com/simple
+      - internal-tool>=1.2
+variables:
+  MODE: prod
+`
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGenerator(Options{OutputFile: path, Format: FormatConda})
+	if err := g.writeCondaEnvironment([]string{"numpy==1.24.3", "requests==2.31.0", `pywin32==306 ; sys_platform == "win32"`}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `name: service
+channels:
+  - conda-forge
+dependencies:
+  - numpy=1.24.3
+  - pywin32=306
+  - pip
+  - pip:
+      # installed from PyPI
+      - requests==2.31.0  # keep on 2.x
+      - --index-url https://pypi.example.com/simple
+      - internal-tool>=1.2
+variables:
+  MODE: prod
+`
+	if string(content) != want {
+		t.Errorf("environment.yml:\n%s\nwant:\n%s", content, want)
+	}
+
+	// The pip section is read back after the conda dependencies
+	dependencies, err := readCondaDependencies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDependencies := []string{"numpy=1.24.3", "pywin32=306", "pip", "requests==2.31.0", "--index-url https://pypi.example.com/simple", "internal-tool>=1.2"}
+	if !reflect.DeepEqual(dependencies, wantDependencies) {
+		t.Errorf("readCondaDependencies = %q, want %q", dependencies, wantDependencies)
+	}
+}
+
+func TestCheckCondaPipSection(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.py": "import numpy\nimport requests\n",
+		"environment.yml": "name: app\ndependencies:\n  - numpy=1.24.3\n  - pip\n  - pip:\n" +
+			"      - requests==2.28.0\n      - internal-tool>=1.2\n",
+	})
+	path := filepath.Join(dir, "environment.yml")
+	g, _ := scanWithFreeze(t, Options{TargetDir: dir, OutputFile: path, Format: FormatConda}, "numpy==1.24.3\nrequests==2.31.0\n")
+
+	added, removed, changed, err := g.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 0 || len(removed) != 0 || !reflect.DeepEqual(changed, []string{"requests==2.28.0 -> requests==2.31.0"}) {
+		t.Errorf("Check() = added %q, removed %q, changed %q, want only requests changed", added, removed, changed)
+	}
+
+	if err := g.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if added, removed, changed, err = g.Check(); err != nil || len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("Check() after Write = added %q, removed %q, changed %q, %v, want up to date", added, removed, changed, err)
+	}
+}
+
+func TestWriteCondaEnvironmentNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myproject")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "environment.yml")
+	g := newTestGenerator(Options{TargetDir: dir, OutputFile: path, Format: FormatConda})
+	if err := g.writeCondaEnvironment([]string{"numpy==1.24.3"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name: myproject\nchannels:\n  - defaults\ndependencies:\n  - numpy=1.24.3\n"; string(content) != want {
+		t.Errorf("environment.yml:\n%s\nwant:\n%s", content, want)
+	}
+}
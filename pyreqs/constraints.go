@@ -0,0 +1,52 @@
+package pyreqs
+
+import "strings"
+
+// readConstraints reads a pip-tools requirements.in file, returning its
+// requirement lines keyed by normalized distribution name. Option lines such
+// as "-r base.in" or "-c constraints.txt" are skipped.
+func readConstraints(path string) (map[string]string, error) {
+	lines, err := readRequirementsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make(map[string]string)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+		constraints[requirementName(line)] = line
+	}
+	return constraints, nil
+}
+
+// filterConstrained keeps the packages declared in the constraints file and
+// warns about the imported packages missing from it.
+func (g *Generator) filterConstrained(packageNames []string) []string {
+	var kept []string
+	for _, pkgName := range packageNames {
+		if _, ok := g.constraints[pep503Normalize(pkgName)]; ok {
+			kept = append(kept, pkgName)
+		} else {
+			g.warn("imported package is not declared in the constraints file", "package", requirementDistribution(g.installedPackages[pkgName]), "constraints", g.constraintsFile)
+		}
+	}
+	return kept
+}
+
+// constrainedRequirement returns the requirement and marker a constraints
+// file line declares, e.g. "Django>=4.2,<5" and `python_version >= "3.10"`.
+// ok is false when the line does not restrict the version, leaving the
+// installed version to be pinned as usual.
+func constrainedRequirement(line string) (requirement, marker string, ok bool) {
+	requirement, marker = splitMarker(line)
+	if _, _, _, direct := directReference(requirement); direct {
+		return requirement, marker, true
+	}
+	specifier := strings.TrimPrefix(requirement, requirementDistribution(requirement))
+	if end := strings.Index(specifier, "]"); strings.HasPrefix(specifier, "[") && end >= 0 {
+		specifier = specifier[end+1:]
+	}
+	return requirement, marker, strings.ContainsAny(specifier, "<>=!~")
+}
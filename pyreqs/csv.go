@@ -0,0 +1,85 @@
+package pyreqs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// csvHeader names the columns written by FormatCSV.
+var csvHeader = []string{"package", "version", "source_files"}
+
+func (g *Generator) writeCSV() error {
+	file, err := createOutputFile(g.outputFile)
+	if err != nil {
+		return err
+	}
+	if err := g.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes one row per requirement of the last Scan to w, after a
+// header row: the distribution name, its installed version and the files
+// importing it, joined by ";".
+func (g *Generator) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, req := range g.Report().Requirements {
+		sources := strings.Join(g.requirementSources(req.Name), ";")
+		if err := writer.Write([]string{req.Name, req.Version, sources}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// requirementSources returns the sorted files importing any module provided
+// by the distribution pkgName. Packages only included as dependencies of
+// other packages have none.
+func (g *Generator) requirementSources(pkgName string) []string {
+	seen := make(map[string]bool)
+	for _, module := range g.packageModules[pep503Normalize(pkgName)] {
+		for _, path := range g.moduleSources[module] {
+			seen[path] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// csvRequirement returns the requirement line a CSV row stands for.
+func csvRequirement(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "==" + version
+}
+
+// readCSVRequirements returns the requirements of a CSV file written by
+// FormatCSV as "name==version" lines.
+func readCSVRequirements(path string) ([]string, error) {
+	content, err := readOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for i, row := range rows {
+		if i == 0 || len(row) < 2 {
+			continue // header
+		}
+		lines = append(lines, csvRequirement(row[0], row[1]))
+	}
+	return lines, nil
+}
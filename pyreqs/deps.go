@@ -0,0 +1,106 @@
+package pyreqs
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// maxDependencyDepth caps how many levels of declared dependencies
+// expandDependencies follows below the imported packages.
+const maxDependencyDepth = 10
+
+// expandDependencies adds the installed packages that the given packages
+// declare as dependencies, recursively, to names. A dependency is a dev
+// dependency only when every package requiring it is. names are keys of
+// installedPackages.
+func (g *Generator) expandDependencies(names []string, dev map[string]bool, installedPackages map[string]string) []string {
+	normalizedInstalled := make(map[string]string)
+	for pkgName := range installedPackages {
+		normalizedInstalled[pep503Normalize(pkgName)] = pkgName
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	level := append([]string{}, names...)
+	for depth := 0; len(level) > 0; depth++ {
+		if depth == maxDependencyDepth {
+			g.warn("dependencies nested too deeply were not included", "max_depth", maxDependencyDepth)
+			break
+		}
+
+		requires, err := g.requiresOf(level)
+		if err != nil {
+			g.warn("could not read package dependencies", "error", err)
+			break
+		}
+
+		var next []string
+		for _, parent := range level {
+			for _, dependency := range requires[pep503Normalize(parent)] {
+				pkgName, ok := normalizedInstalled[pep503Normalize(dependency)]
+				if !ok {
+					g.logger.Debug("dependency not installed, skipped", "package", parent, "dependency", dependency)
+					continue
+				}
+				parentKey := pep503Normalize(parent)
+				g.dependencyGraph[parentKey] = append(g.dependencyGraph[parentKey], pep503Normalize(dependency))
+				if seen[pkgName] {
+					// Reachable from a runtime package means it is needed at runtime
+					if !dev[parent] {
+						dev[pkgName] = false
+					}
+					continue
+				}
+				g.logger.Debug("dependency included", "package", pkgName, "required_by", parent)
+				seen[pkgName] = true
+				dev[pkgName] = dev[parent]
+				next = append(next, pkgName)
+			}
+		}
+		names = append(names, next...)
+		level = next
+	}
+
+	return names
+}
+
+// requiresOf runs "pip show" for pkgNames and returns the names listed in the
+// Requires field of each, keyed by normalized package name.
+func (g *Generator) requiresOf(pkgNames []string) (map[string][]string, error) {
+	output, err := g.runCommand("pip show", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.pipCommand(ctx, append([]string{"show"}, pkgNames...)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsePipShow(string(output)), nil
+}
+
+// parsePipShow reads the Name and Requires fields of the "---" separated
+// records printed by "pip show".
+func parsePipShow(output string) map[string][]string {
+	requires := make(map[string][]string)
+	var name string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "---":
+			name = ""
+		case strings.HasPrefix(line, "Name:"):
+			name = pep503Normalize(strings.TrimSpace(strings.TrimPrefix(line, "Name:")))
+		case strings.HasPrefix(line, "Requires:") && name != "":
+			for _, dependency := range strings.Split(strings.TrimPrefix(line, "Requires:"), ",") {
+				if dependency = strings.TrimSpace(dependency); dependency != "" {
+					requires[name] = append(requires[name], dependency)
+				}
+			}
+		}
+	}
+	return requires
+}
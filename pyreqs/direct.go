@@ -0,0 +1,57 @@
+package pyreqs
+
+import "strings"
+
+// directReference splits an editable or direct-reference requirement as
+// printed by pip freeze, "-e git+https://host/repo.git@ref#egg=name" or
+// "name @ git+https://host/repo.git@ref", into its distribution name and URL.
+// ok is false for other lines.
+func directReference(line string) (name, url string, editable, ok bool) {
+	switch {
+	case strings.HasPrefix(line, "-e ") || strings.HasPrefix(line, "--editable "):
+		url = strings.TrimSpace(line[strings.Index(line, " "):])
+		fragment := strings.Index(url, "#")
+		if fragment < 0 {
+			return "", "", false, false
+		}
+		for _, option := range strings.Split(url[fragment+1:], "&") {
+			if strings.HasPrefix(option, "egg=") {
+				name = strings.TrimPrefix(option, "egg=")
+			}
+		}
+		if name == "" {
+			return "", "", false, false
+		}
+		return name, url[:fragment], true, true
+	case strings.Contains(line, " @ "):
+		at := strings.Index(line, " @ ")
+		name = strings.TrimSpace(line[:at])
+		if bracket := strings.Index(name, "["); bracket >= 0 {
+			name = name[:bracket]
+		}
+		return name, strings.TrimSpace(line[at+3:]), false, true
+	}
+	return "", "", false, false
+}
+
+// pep508Requirements rewrites editable requirements into "name @ url" direct
+// references, the only form pyproject.toml and setup.py dependencies accept.
+func pep508Requirements(requirements []string) []string {
+	converted := make([]string, 0, len(requirements))
+	for _, req := range requirements {
+		if name, url, editable, ok := directReference(req); ok && editable {
+			req = name + " @ " + url
+		}
+		converted = append(converted, req)
+	}
+	return converted
+}
+
+// splitVCSRef splits "git+https://host/repo.git@ref" into the repository URL
+// and the ref, which is empty when the URL names none.
+func splitVCSRef(url string) (repository, ref string) {
+	if at := strings.LastIndex(url, "@"); at > strings.LastIndex(url, "/") {
+		return url[:at], url[at+1:]
+	}
+	return url, ""
+}
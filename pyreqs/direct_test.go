@@ -0,0 +1,53 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDirectReference(t *testing.T) {
+	tests := []struct {
+		line     string
+		name     string
+		url      string
+		editable bool
+		ok       bool
+	}{
+		{"-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg", "mypkg", "git+https://github.com/acme/mypkg.git@1a2b3c", true, true},
+		{"--editable git+https://github.com/acme/mypkg.git#subdirectory=src&egg=mypkg", "mypkg", "git+https://github.com/acme/mypkg.git", true, true},
+		{"mypkg @ git+https://github.com/acme/mypkg.git@v1.0", "mypkg", "git+https://github.com/acme/mypkg.git@v1.0", false, true},
+		{"mypkg[cli] @ file:///src/mypkg", "mypkg", "file:///src/mypkg", false, true},
+		{"-e /src/mypkg", "", "", false, false},
+		{"requests==2.31.0", "", "", false, false},
+	}
+	for _, tt := range tests {
+		name, url, editable, ok := directReference(tt.line)
+		if name != tt.name || url != tt.url || editable != tt.editable || ok != tt.ok {
+			t.Errorf("directReference(%q) = %q, %q, %v, %v, want %q, %q, %v, %v",
+				tt.line, name, url, editable, ok, tt.name, tt.url, tt.editable, tt.ok)
+		}
+	}
+}
+
+func TestRequirementsKeepInstallSpec(t *testing.T) {
+	freeze := "-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg\n" +
+		"otherpkg @ git+https://github.com/acme/otherpkg.git@v2.0\n" +
+		"requests==2.31.0\n"
+	got := requirementsFor(t, Options{}, freeze, "import mypkg\nimport otherpkg\nimport requests\n")
+	want := []string{
+		"-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg",
+		"otherpkg @ git+https://github.com/acme/otherpkg.git@v2.0",
+		"requests==2.31.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+}
+
+func TestPep508Requirements(t *testing.T) {
+	got := pep508Requirements([]string{"-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg", "requests==2.31.0"})
+	want := []string{"mypkg @ git+https://github.com/acme/mypkg.git@1a2b3c", "requests==2.31.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pep508Requirements = %v, want %v", got, want)
+	}
+}
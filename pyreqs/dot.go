@@ -0,0 +1,82 @@
+package pyreqs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func (g *Generator) writeDotFile() error {
+	file, err := createOutputFile(g.outputFile)
+	if err != nil {
+		return err
+	}
+	if err := g.WriteDot(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteDot writes the dependency graph found by the last Scan to w as a
+// Graphviz DOT digraph: one node per requirement, filled when it is imported
+// directly, and an edge from each package to every package it requires.
+func (g *Generator) WriteDot(w io.Writer) error {
+	// Name each node as written in the requirements
+	names := make(map[string]string)
+	for _, line := range append(append([]string{}, g.requirements...), g.devRequirements...) {
+		name := requirementDistribution(line)
+		names[pep503Normalize(name)] = name
+	}
+
+	graph := make(map[string][]string)
+	direct := make(map[string]bool)
+	for pkgName, name := range names {
+		graph[name] = nil
+		direct[name] = g.directPackages[pkgName]
+		for _, dependency := range g.dependencyGraph[pkgName] {
+			// Leave out dependencies filtered from the requirements
+			if dependencyName, ok := names[dependency]; ok {
+				graph[name] = append(graph[name], dependencyName)
+			}
+		}
+	}
+	return writeDot(w, graph, direct)
+}
+
+// writeDot writes graph, mapping each package to the packages it requires,
+// as a DOT digraph with the packages in direct drawn filled.
+func writeDot(w io.Writer, graph map[string][]string, direct map[string]bool) error {
+	var b strings.Builder
+	b.WriteString("digraph requirements {\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	nodes := make(map[string]bool)
+	for name, requires := range graph {
+		nodes[name] = true
+		for _, dependency := range requires {
+			nodes[dependency] = true
+		}
+	}
+	for _, name := range sortedKeys(nodes) {
+		if direct[name] {
+			fmt.Fprintf(&b, "\t%s [style=filled];\n", strconv.Quote(name))
+		} else {
+			fmt.Fprintf(&b, "\t%s;\n", strconv.Quote(name))
+		}
+	}
+
+	for _, name := range sortedKeys(graph) {
+		requires := append([]string{}, graph[name]...)
+		sort.Strings(requires)
+		for _, dependency := range requires {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(name), strconv.Quote(dependency))
+		}
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,144 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	// entryPointRegex matches a "name = module:attr" line of setup.cfg,
+	// capturing the module.
+	entryPointRegex = regexp.MustCompile(`^\s*[\w.-]+\s*=\s*([A-Za-z_][\w.]*)\s*:\s*[A-Za-z_][\w.]*\s*(?:\[[^\]]*\])?\s*$`)
+	// setupPyEntryPointRegex matches a "name = module:attr" string literal in
+	// a setup.py, capturing the module.
+	setupPyEntryPointRegex = regexp.MustCompile(`['"]\s*[\w.-]+\s*=\s*([A-Za-z_][\w.]*)\s*:\s*[A-Za-z_][\w.]*\s*(?:\[[^\]]*\])?\s*['"]`)
+	iniSectionRegex        = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+)
+
+// addEntryPointModules adds the modules named by the entry points, such as
+// console_scripts, declared in the setup.py, setup.cfg and pyproject.toml of
+// each target directory. Plugin frameworks load these modules by name, so
+// they may never be imported by the scanned code.
+func (g *Generator) addEntryPointModules() {
+	for _, dir := range g.targetDirs {
+		readers := map[string]func(string) ([]string, error){
+			"setup.py":       readSetupPyEntryPoints,
+			"setup.cfg":      readSetupCfgEntryPoints,
+			"pyproject.toml": readPyprojectEntryPoints,
+		}
+		for _, name := range sortedKeys(readers) {
+			path := filepath.Join(dir, name)
+			modules, err := readers[name](path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				g.warn("could not read entry points", "file", path, "error", err)
+				continue
+			}
+			// Entry points are not import statements, so they have no line
+			imports := make([]Import, len(modules))
+			for i, module := range modules {
+				imports[i] = Import{Name: g.moduleName(module)}
+			}
+			g.logger.Debug("found entry point modules", "file", path, "modules", importNames(imports))
+			g.addModules(path, imports)
+		}
+	}
+}
+
+// readSetupPyEntryPoints returns the modules of the "name = module:attr"
+// strings in a setup.py.
+func readSetupPyEntryPoints(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []string
+	for _, match := range setupPyEntryPointRegex.FindAllStringSubmatch(stripCommentsAndDocstrings(string(content)), -1) {
+		modules = append(modules, match[1])
+	}
+	return modules, nil
+}
+
+// readSetupCfgEntryPoints returns the modules of the entry points in the
+// [options.entry_points] section of a setup.cfg.
+func readSetupCfgEntryPoints(path string) ([]string, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []string
+	inSection := false
+	for _, line := range lines {
+		if match := iniSectionRegex.FindStringSubmatch(line); match != nil {
+			inSection = strings.TrimSpace(match[1]) == "options.entry_points"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if match := entryPointRegex.FindStringSubmatch(line); match != nil {
+			modules = append(modules, match[1])
+		}
+	}
+	return modules, nil
+}
+
+// readPyprojectEntryPoints returns the modules of the [project.scripts],
+// [project.gui-scripts] and [project.entry-points] tables of a
+// pyproject.toml, and of Poetry's [tool.poetry.scripts] and
+// [tool.poetry.plugins].
+func readPyprojectEntryPoints(path string) ([]string, error) {
+	var pyproject struct {
+		Project struct {
+			Scripts     map[string]string            `toml:"scripts"`
+			GUIScripts  map[string]string            `toml:"gui-scripts"`
+			EntryPoints map[string]map[string]string `toml:"entry-points"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Scripts map[string]interface{}       `toml:"scripts"`
+				Plugins map[string]map[string]string `toml:"plugins"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string)
+	for name, target := range pyproject.Project.Scripts {
+		targets[name] = target
+	}
+	for name, target := range pyproject.Project.GUIScripts {
+		targets[name] = target
+	}
+	for name, target := range pyproject.Tool.Poetry.Scripts {
+		// Script tables such as { reference = "...", type = "file" } are not entry points
+		if target, ok := target.(string); ok {
+			targets[name] = target
+		}
+	}
+	for _, groups := range []map[string]map[string]string{pyproject.Project.EntryPoints, pyproject.Tool.Poetry.Plugins} {
+		for group, entryPoints := range groups {
+			for name, target := range entryPoints {
+				targets[group+"."+name] = target
+			}
+		}
+	}
+
+	var modules []string
+	for _, name := range sortedKeys(targets) {
+		if module, _, ok := strings.Cut(targets[name], ":"); ok {
+			modules = append(modules, strings.TrimSpace(module))
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,26 @@
+package pyreqs
+
+import "fmt"
+
+// DirectoryNotFoundError is returned by Scan when a target directory does not
+// exist.
+type DirectoryNotFoundError struct {
+	Dir string
+}
+
+func (e *DirectoryNotFoundError) Error() string {
+	return fmt.Sprintf("directory '%s' not found", e.Dir)
+}
+
+// ExecutableNotFoundError is returned by Scan when the pip, python or conda
+// executable used to list the installed packages cannot be found.
+type ExecutableNotFoundError struct {
+	// Kind describes the executable, e.g. "pip executable".
+	Kind string
+	// Name is the name or path that was looked up.
+	Name string
+}
+
+func (e *ExecutableNotFoundError) Error() string {
+	return fmt.Sprintf("%s '%s' not found", e.Kind, e.Name)
+}
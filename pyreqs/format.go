@@ -0,0 +1,72 @@
+package pyreqs
+
+import "fmt"
+
+// Format selects the kind of file Write produces.
+type Format string
+
+const (
+	// FormatTxt writes a plain requirements.txt file, one requirement per line.
+	FormatTxt Format = "txt"
+	// FormatPyproject updates the [project] dependencies array of a pyproject.toml.
+	FormatPyproject Format = "pyproject"
+	// FormatJSON writes an indented JSON Report.
+	FormatJSON Format = "json"
+	// FormatSetup rewrites install_requires in the setup() call of a setup.py.
+	FormatSetup Format = "setup"
+	// FormatSetupCfg rewrites install_requires in the [options] section of a
+	// setup.cfg. Prefer it over FormatSetup: plain INI round-trips safely,
+	// whereas setup.py is arbitrary Python.
+	FormatSetupCfg Format = "setupcfg"
+	// FormatPipfile rewrites the [packages] table of a Pipenv Pipfile.
+	FormatPipfile Format = "pipfile"
+	// FormatConda rewrites the dependencies list of a conda environment.yml.
+	FormatConda Format = "conda"
+	// FormatYAML writes the requirement lines and unresolved modules of the
+	// Report as a YAML document.
+	FormatYAML Format = "yaml"
+	// FormatCSV writes a package,version,source_files table for spreadsheets.
+	FormatCSV Format = "csv"
+	// FormatPoetry rewrites the [tool.poetry.dependencies] table of a
+	// pyproject.toml. It defaults to PinCaret.
+	FormatPoetry Format = "poetry"
+	// FormatDot writes the dependency graph of the requirements as a Graphviz
+	// DOT file. It implies WithDeps.
+	FormatDot Format = "dot"
+)
+
+// ParseFormat converts a flag value into a Format.
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatTxt, FormatPyproject, FormatJSON, FormatYAML, FormatSetup, FormatSetupCfg, FormatPipfile, FormatConda, FormatCSV, FormatDot, FormatPoetry:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format '%s' (want txt, pyproject, json, yaml, setup, setupcfg, pipfile, conda, csv, dot or poetry)", value)
+}
+
+// DefaultOutputFile returns the file name written for format when no output
+// file is configured.
+func DefaultOutputFile(format Format) string {
+	switch format {
+	case FormatPyproject, FormatPoetry:
+		return "pyproject.toml"
+	case FormatJSON:
+		return "requirements.json"
+	case FormatYAML:
+		return "requirements.yaml"
+	case FormatSetup:
+		return "setup.py"
+	case FormatSetupCfg:
+		return "setup.cfg"
+	case FormatPipfile:
+		return "Pipfile"
+	case FormatConda:
+		return "environment.yml"
+	case FormatCSV:
+		return "requirements.csv"
+	case FormatDot:
+		return "requirements.dot"
+	default:
+		return "requirements.txt"
+	}
+}
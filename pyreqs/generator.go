@@ -0,0 +1,1216 @@
+// Package pyreqs scans Python projects for imported modules and resolves them
+// against the packages installed in the current environment, producing the
+// lines of a requirements.txt file.
+package pyreqs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DefaultExcludedDirs lists directory names that never contain project code,
+// such as virtual environments, installed packages and caches.
+var DefaultExcludedDirs = []string{
+	".venv", "venv", "env", ".env", "site-packages", "__pycache__",
+	".tox", ".nox", ".git", ".hg", ".mypy_cache", ".pytest_cache", "node_modules",
+}
+
+// headerPrefix starts the first line of a generated header comment.
+const headerPrefix = "# Generated by go-pyreqs"
+
+// stdinName stands for the source read by ScanReader in provenance records.
+const stdinName = "<stdin>"
+
+// Options configures a Generator.
+type Options struct {
+	// TargetDir is the directory scanned for Python files. Defaults to "."
+	// unless TargetDirs is set.
+	TargetDir string
+	// TargetDirs lists further directories scanned into the same requirements.
+	TargetDirs []string
+	// OutputFile is the file written by Write. Defaults to DefaultOutputFile(Format).
+	OutputFile string
+	// DevOutputFile, when set, receives the requirements whose packages are
+	// only imported by test files, in the txt format. They are left out of
+	// OutputFile.
+	DevOutputFile string
+	// Header starts requirements.txt files with a comment naming the tool
+	// Version, the generation time and the Command that produced them.
+	Header bool
+	// IncludeUnresolved appends the imports no installed package provides to
+	// requirements.txt output as "# unresolved: name" comments, as a
+	// reminder to install or declare them (txt format only).
+	IncludeUnresolved bool
+	// UncommentUnresolved writes those imports as bare "name  # unresolved"
+	// requirement lines instead, so installing fails until they are fixed.
+	UncommentUnresolved bool
+	// GroupByDir arranges requirements.txt output into "# from dir/"
+	// sections by the directory of the importing files, GroupDepth levels
+	// below the target (default 1) (txt format only). GroupCommon lists
+	// requirements imported from several directories once, in a "# common"
+	// section, instead of in each.
+	GroupByDir  bool
+	GroupDepth  int
+	GroupCommon bool
+	// Version is the tool version written in the header.
+	Version string
+	// Command is the invocation written in the header, so others can
+	// regenerate the file.
+	Command string
+	// Merge folds the requirements into an existing requirements.txt instead
+	// of replacing it, keeping comments, options and hand-written constraints.
+	// It applies to FormatTxt and the dev output file.
+	Merge bool
+	// AllowEmpty lets Write create or overwrite an output file even when no
+	// requirements were found. Without it such files are left untouched.
+	AllowEmpty bool
+	// Format selects the kind of output file. Defaults to FormatTxt.
+	Format Format
+	// Template, when set, replaces the output format: the output file is
+	// the template executed with the TemplateData of the Scan. See
+	// ParseTemplateFile.
+	Template *template.Template
+	// ExcludeDirs lists directory names to skip in addition to DefaultExcludedDirs.
+	ExcludeDirs []string
+	// ExcludePaths lists directories to skip by path rather than by name,
+	// e.g. nested projects that get their own requirements.
+	ExcludePaths []string
+	// IncludePatterns lists glob patterns, matched against base names, of
+	// further files to scan as Python code, e.g. "*.pyw".
+	IncludePatterns []string
+	// ExcludePatterns lists glob patterns, matched against base names, of
+	// files never scanned, even .py files.
+	ExcludePatterns []string
+	// FollowSymlinks also walks directories that symlinks point to. Each
+	// directory is walked at most once, so symlink cycles are safe.
+	FollowSymlinks bool
+	// NoRecursive scans only the files directly in each target directory.
+	NoRecursive bool
+	// MaxDepth limits how many directory levels below each target are
+	// scanned; 0 means no limit.
+	MaxDepth int
+	// UseGitignore skips paths ignored by .gitignore files in and above the
+	// target directories.
+	UseGitignore bool
+	// DynamicImports also detects modules loaded by importlib.import_module
+	// and __import__ calls with a string literal name. Any matching string
+	// counts, so this can report modules that are never actually imported.
+	DynamicImports bool
+	// ScanEntryPoints also adds the modules named by the entry points, such
+	// as console_scripts, of the setup.py, setup.cfg and pyproject.toml in
+	// each target directory.
+	ScanEntryPoints bool
+	// ScanDoctests also detects the imports of ">>> import x" doctest
+	// examples in docstrings, which are otherwise ignored.
+	ScanDoctests bool
+	// ExcludeTypeChecking skips imports inside "if TYPE_CHECKING:" blocks,
+	// which are only made for type checkers.
+	ExcludeTypeChecking bool
+	// Since, when set to a git ref, only scans the files changed since that
+	// ref, plus unchanged files that may import a module the changed files
+	// used to, and keeps the other requirements of the existing output file
+	// (txt format). Without a git repository knowing the ref, every file is
+	// scanned.
+	Since string
+	// Parser selects how imports are extracted. Defaults to ParserRegex.
+	Parser ParserBackend
+	// IncludeNotebooks also scans the code cells of Jupyter .ipynb files.
+	IncludeNotebooks bool
+	// PinStyle selects how versions are pinned. Defaults to PinExact.
+	PinStyle PinStyle
+	// Sort selects the order of the requirement lines. Defaults to SortName.
+	Sort SortOrder
+	// ImportMappings adds import name to distribution name mappings, taking
+	// precedence over the built-in table.
+	ImportMappings map[string]string
+	// Resolvers are tried in order to find the package of each third-party
+	// module before ImportMappings and the built-in matching.
+	Resolvers []Resolver
+	// Markers attaches a PEP 508 environment marker to the requirement of a
+	// distribution, keyed by distribution name, e.g. "pywin32" to
+	// `sys_platform == "win32"`.
+	Markers map[string]string
+	// VersionOverrides pins a distribution, keyed by distribution name, to
+	// the given version instead of the installed one, e.g. to avoid pinning
+	// a local build such as "1.0.0+local".
+	VersionOverrides map[string]string
+	// NamespaceDepths sets, per namespace root such as "google" or
+	// "google.cloud", how many leading components of an import name identify
+	// a distribution, overriding DefaultNamespaceDepths. A depth of 1 matches
+	// by top-level name only.
+	NamespaceDepths map[string]int
+	// IgnoreModules lists imported modules that are never turned into requirements.
+	IgnoreModules []string
+	// IgnorePackages lists distributions that are never written as
+	// requirements, even when an imported module matches them or another
+	// package depends on them.
+	IgnorePackages []string
+	// ConstraintsFile, when set, is a pip-tools requirements.in file: only
+	// imported packages declared in it become requirements, keeping its
+	// version specifiers, and imported packages missing from it are warned
+	// about.
+	ConstraintsFile string
+	// OnlyPackages, when non-empty, restricts the requirements to these
+	// distribution names; other matched packages are left out.
+	OnlyPackages []string
+	// Source selects how installed packages are listed. Defaults to SourceFreeze.
+	Source Source
+	// Conda is the conda executable run for SourceConda. Defaults to "conda".
+	Conda string
+	// Pip is the pip executable to run. Defaults to "pip".
+	Pip string
+	// Python, when set, runs "<Python> -m pip" instead of Pip.
+	Python string
+	// FreezeFile, when set, is read as saved "pip freeze" output instead of
+	// asking pip for the installed packages, e.g. for an environment on
+	// another machine. Source and NoCache then do not apply.
+	FreezeFile string
+	// NoVirtualenv disables using the interpreter of a .venv or venv directory
+	// found in the first target directory. An explicit Python always takes
+	// precedence.
+	NoVirtualenv bool
+	// TargetPythonMinor is the Python 3 minor version, e.g. 11 for 3.11,
+	// whose standard library is left out of the requirements. When 0 it is
+	// asked from the interpreter, and any module of a supported Python 3
+	// release counts as standard library if that fails.
+	TargetPythonMinor int
+	// PipTimeout bounds how long pip or conda may take to list the installed
+	// packages. Defaults to DefaultPipTimeout.
+	PipTimeout time.Duration
+	// PipRetries is how many more times listing the installed packages is
+	// attempted when pip or conda fails to start, waiting twice as long
+	// before each retry.
+	PipRetries int
+	// NoCache always runs pip instead of reusing a recent cached package list.
+	NoCache bool
+	// Jobs is the number of files scanned in parallel. Defaults to runtime.NumCPU().
+	Jobs int
+	// ModulesOnly writes the sorted third-party modules imported by the
+	// project instead of requirements, without listing installed packages
+	// or running Python at all.
+	ModulesOnly bool
+	// WithDeps also includes the installed dependencies declared by each
+	// matched package, recursively, as read from "pip show".
+	WithDeps bool
+	// KeepExtras keeps the extras of installed packages reported with them,
+	// such as "requests[security]==2.31.0"; by default they are dropped.
+	KeepExtras bool
+	// StripLocalVersion drops the local version label of installed builds,
+	// writing "torch==2.1.0" for "torch==2.1.0+cu118", so the requirement
+	// also installs on machines without that build.
+	StripLocalVersion bool
+	// ReportUnused collects the installed packages no scanned import needs,
+	// leaving out the dependencies of other installed packages (see Unused).
+	ReportUnused bool
+	// GenerateHashes looks up the sha256 hashes of each exactly pinned
+	// requirement on PyPI and writes them as --hash options (txt format only).
+	GenerateHashes bool
+	// Verbose logs the imports of every file and each matching decision to stderr.
+	// It is ignored when Logger is set.
+	Verbose bool
+	// Progress, when set, is called while files are scanned with the number of
+	// files scanned so far and the distinct modules they import: every 500
+	// files or 2 seconds, and once after the last file.
+	Progress func(files, modules int)
+	// Logger receives, at debug level, the imports of every file, each
+	// matching decision and each warning as it occurs; warnings are
+	// otherwise only collected, for Warnings and LogWarnings. Defaults to a
+	// text logger on stderr at warn level, or debug level with Verbose.
+	Logger *slog.Logger
+}
+
+// Generator scans a Python project and builds its requirement lines.
+type Generator struct {
+	targetDirs          []string
+	outputFile          string
+	devOutputFile       string
+	allowEmpty          bool
+	merge               bool
+	header              bool
+	includeUnresolved   bool
+	uncommentUnresolved bool
+	groupByDir          bool
+	groupDepth          int
+	groupCommon         bool
+	version             string
+	command             string
+	format              Format
+	template            *template.Template
+	excludedDirs        map[string]bool
+	excludedPaths       map[string]bool
+	includePatterns     []string
+	excludePatterns     []string
+	useGitignore        bool
+	followSymlinks      bool
+	maxDepth            int // -1 means no limit
+	includeNotebooks    bool
+	dynamicImports      bool
+	excludeTypeChecking bool
+	parserBackend       ParserBackend
+	parser              Parser
+	since               string
+	sinceModules        map[string]bool // imported by earlier versions of changed files
+	scanDoctests        bool
+	scanEntryPoints     bool
+	jobs                int
+	logger              *slog.Logger
+	warnings            []warning
+	duplicatePackages   []duplicatePackage // lines dropped by parseFreeze, for the package cache
+	progress            func(files, modules int)
+	reportUnused        bool
+	generateHashes      bool
+	keepExtras          bool
+	stripLocalVersion   bool
+	modulesOnly         bool
+	withDeps            bool
+	pinStyle            PinStyle
+	sortOrder           SortOrder
+	source              Source
+	importMappings      map[string]string
+	resolvers           []Resolver
+	metadataMappings    map[string]string // from importlib.metadata with SourceMetadata
+	ignoreModules       map[string]bool
+	ignorePackages      map[string]bool
+	onlyPackages        map[string]bool
+	constraintsFile     string
+	constraints         map[string]string // declared requirement by normalized name
+	markers             map[string]string
+	namespaceDepths     map[string]int
+	versionOverrides    map[string]string
+	pip                 string
+	conda               string
+	python              string
+	venvPath            string
+	noCache             bool
+	freezeFile          string
+	pipTimeout          time.Duration
+	pipRetries          int
+	mu                  sync.Mutex // guards foundModules, moduleOrder, moduleSources and importSites
+	foundModules        map[string]bool
+	moduleOrder         map[string]int // discovery index of each found module
+	moduleSources       map[string][]string
+	importSites         map[string][]ImportSite
+	localModules        map[string]bool
+	pythonMinor         int // minimum Python 3 minor version found, or 0
+	targetPythonMinor   int // Python 3 minor version of the standard library, or 0
+	testFiles           map[string]bool
+	installedPackages   map[string]string
+	packageModules      map[string][]string // modules provided by each normalized package name
+	directPackages      map[string]bool     // normalized names of the directly imported packages
+	dependencyGraph     map[string][]string // normalized names of the packages each one requires
+	requirements        []string
+	devRequirements     []string
+	hashes              map[string][]string
+	unresolved          []string
+	unused              []string
+	stats               Stats
+}
+
+// NewGenerator returns a Generator configured by opts.
+func NewGenerator(opts Options) *Generator {
+	var targetDirs []string
+	if opts.TargetDir != "" {
+		targetDirs = append(targetDirs, opts.TargetDir)
+	}
+	targetDirs = append(targetDirs, opts.TargetDirs...)
+	if len(targetDirs) == 0 {
+		targetDirs = []string{"."}
+	}
+	if opts.Format == "" {
+		opts.Format = FormatTxt
+	}
+	if opts.OutputFile == "" {
+		opts.OutputFile = DefaultOutputFile(opts.Format)
+	}
+	if opts.PinStyle == "" && opts.Format == FormatPoetry {
+		opts.PinStyle = PinCaret
+	} else if opts.PinStyle == "" {
+		opts.PinStyle = PinExact
+	}
+	if opts.Sort == "" {
+		opts.Sort = SortName
+	}
+	if opts.Parser == "" {
+		opts.Parser = ParserRegex
+	}
+	if opts.GroupDepth < 1 {
+		opts.GroupDepth = 1
+	}
+	if opts.Source == "" {
+		opts.Source = SourceFreeze
+	}
+	if opts.Pip == "" {
+		opts.Pip = "pip"
+	}
+	if opts.Conda == "" {
+		opts.Conda = "conda"
+	}
+	if opts.PipTimeout <= 0 {
+		opts.PipTimeout = DefaultPipTimeout
+	}
+	if opts.Jobs <= 0 {
+		opts.Jobs = runtime.NumCPU()
+	}
+
+	ignoreModules := make(map[string]bool)
+	for _, module := range opts.IgnoreModules {
+		ignoreModules[pep503Normalize(module)] = true
+	}
+	ignorePackages := make(map[string]bool)
+	for _, pkgName := range opts.IgnorePackages {
+		ignorePackages[pep503Normalize(pkgName)] = true
+	}
+
+	maxDepth := -1
+	if opts.NoRecursive {
+		maxDepth = 0
+	} else if opts.MaxDepth > 0 {
+		maxDepth = opts.MaxDepth
+	}
+
+	var onlyPackages map[string]bool
+	if len(opts.OnlyPackages) > 0 {
+		onlyPackages = make(map[string]bool)
+		for _, pkgName := range opts.OnlyPackages {
+			onlyPackages[pep503Normalize(pkgName)] = true
+		}
+	}
+
+	markers := make(map[string]string)
+	for pkgName, marker := range opts.Markers {
+		markers[pep503Normalize(pkgName)] = marker
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		level := slog.LevelWarn
+		if opts.Verbose {
+			level = slog.LevelDebug
+		}
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	}
+
+	versionOverrides := make(map[string]string)
+	for pkgName, version := range opts.VersionOverrides {
+		versionOverrides[pep503Normalize(pkgName)] = version
+	}
+
+	namespaceDepths := make(map[string]int)
+	for root, depth := range DefaultNamespaceDepths {
+		namespaceDepths[root] = depth
+	}
+	for root, depth := range opts.NamespaceDepths {
+		namespaceDepths[root] = depth
+	}
+
+	excludedDirs := make(map[string]bool)
+	for _, dir := range DefaultExcludedDirs {
+		excludedDirs[dir] = true
+	}
+	for _, dir := range opts.ExcludeDirs {
+		excludedDirs[dir] = true
+	}
+
+	excludedPaths := make(map[string]bool)
+	for _, dir := range opts.ExcludePaths {
+		if abs, err := filepath.Abs(dir); err == nil {
+			excludedPaths[abs] = true
+		}
+	}
+
+	generator := &Generator{
+		targetDirs:          targetDirs,
+		outputFile:          opts.OutputFile,
+		devOutputFile:       opts.DevOutputFile,
+		allowEmpty:          opts.AllowEmpty,
+		merge:               opts.Merge,
+		header:              opts.Header,
+		includeUnresolved:   opts.IncludeUnresolved,
+		uncommentUnresolved: opts.UncommentUnresolved,
+		groupByDir:          opts.GroupByDir,
+		groupDepth:          opts.GroupDepth,
+		groupCommon:         opts.GroupCommon,
+		version:             opts.Version,
+		command:             opts.Command,
+		format:              opts.Format,
+		template:            opts.Template,
+		excludedDirs:        excludedDirs,
+		excludedPaths:       excludedPaths,
+		includePatterns:     opts.IncludePatterns,
+		excludePatterns:     opts.ExcludePatterns,
+		useGitignore:        opts.UseGitignore,
+		followSymlinks:      opts.FollowSymlinks,
+		maxDepth:            maxDepth,
+		includeNotebooks:    opts.IncludeNotebooks,
+		dynamicImports:      opts.DynamicImports,
+		excludeTypeChecking: opts.ExcludeTypeChecking,
+		parserBackend:       opts.Parser,
+		since:               opts.Since,
+		sinceModules:        make(map[string]bool),
+		scanDoctests:        opts.ScanDoctests,
+		scanEntryPoints:     opts.ScanEntryPoints,
+		jobs:                opts.Jobs,
+		logger:              logger,
+		progress:            opts.Progress,
+		reportUnused:        opts.ReportUnused,
+		generateHashes:      opts.GenerateHashes,
+		keepExtras:          opts.KeepExtras,
+		stripLocalVersion:   opts.StripLocalVersion,
+		modulesOnly:         opts.ModulesOnly,
+		withDeps:            opts.WithDeps || opts.Format == FormatDot,
+		pinStyle:            opts.PinStyle,
+		sortOrder:           opts.Sort,
+		source:              opts.Source,
+		importMappings:      opts.ImportMappings,
+		resolvers:           opts.Resolvers,
+		ignoreModules:       ignoreModules,
+		ignorePackages:      ignorePackages,
+		onlyPackages:        onlyPackages,
+		constraintsFile:     opts.ConstraintsFile,
+		markers:             markers,
+		namespaceDepths:     namespaceDepths,
+		versionOverrides:    versionOverrides,
+		pip:                 opts.Pip,
+		conda:               opts.Conda,
+		python:              opts.Python,
+		targetPythonMinor:   opts.TargetPythonMinor,
+		noCache:             opts.NoCache,
+		freezeFile:          opts.FreezeFile,
+		pipTimeout:          opts.PipTimeout,
+		pipRetries:          opts.PipRetries,
+		foundModules:        make(map[string]bool),
+		moduleOrder:         make(map[string]int),
+		moduleSources:       make(map[string][]string),
+		importSites:         make(map[string][]ImportSite),
+		directPackages:      make(map[string]bool),
+		dependencyGraph:     make(map[string][]string),
+		localModules:        make(map[string]bool),
+		testFiles:           make(map[string]bool),
+	}
+
+	// Prefer the project's own virtualenv over whatever pip is on PATH
+	if opts.Python == "" && !opts.NoVirtualenv {
+		if venv, ok := detectVirtualenv(targetDirs[0]); ok {
+			generator.venvPath = venv
+		}
+	}
+
+	return generator
+}
+
+// Scan walks the target directories, matches the modules it imports against the
+// installed packages and returns the resulting requirement lines.
+func (g *Generator) Scan() ([]string, error) {
+	start := time.Now()
+	defer func() { g.stats.Elapsed = time.Since(start) }()
+
+	// Check that every target directory exists
+	for _, dir := range g.targetDirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, &DirectoryNotFoundError{Dir: dir}
+		}
+	}
+
+	// Find and process all Python files
+	g.selectParser()
+	g.checkSince()
+	if err := g.findAndProcessPythonFiles(); err != nil {
+		return nil, fmt.Errorf("failed to process Python files: %v", err)
+	}
+	if g.scanEntryPoints {
+		g.addEntryPointModules()
+	}
+
+	return g.resolve()
+}
+
+// ScanReader reads Python source code from r instead of walking the target
+// directories, matches the modules it imports against the installed packages
+// and returns the resulting requirement lines.
+func (g *Generator) ScanReader(r io.Reader) ([]string, error) {
+	start := time.Now()
+	defer func() { g.stats.Elapsed = time.Since(start) }()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Python code: %v", err)
+	}
+
+	source := normalizeSource(content)
+	g.selectParser()
+	imports := g.extractImports(stdinName, source)
+	g.logger.Debug("found imports", "file", stdinName, "modules", importNames(imports))
+	g.addModules(stdinName, imports)
+	g.stats.FilesScanned++
+	g.requirePython(stdinName, detectPythonVersion(source, importNames(imports)))
+
+	return g.resolve()
+}
+
+// resolve matches the found modules against the installed packages.
+func (g *Generator) resolve() ([]string, error) {
+	if g.modulesOnly {
+		g.requirements = g.externalModules()
+		g.stats.ImportsFound = len(g.foundModules)
+		g.stats.Requirements = len(g.requirements)
+		return g.requirements, nil
+	}
+
+	// Get installed packages
+	installedPackages, err := g.getInstalledPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installed packages: %w", err)
+	}
+
+	g.installedPackages = installedPackages
+	if g.constraintsFile != "" {
+		if g.constraints, err = readConstraints(g.constraintsFile); err != nil {
+			return nil, fmt.Errorf("failed to read constraints file: %v", err)
+		}
+	}
+	if g.source == SourceMetadata {
+		mappings, err := g.queryMetadataMappings()
+		if err != nil {
+			g.warn("could not query importlib.metadata, using the built-in import name mappings", "error", err)
+		}
+		g.metadataMappings = mappings
+	}
+	if g.targetPythonMinor == 0 {
+		if minor, err := g.interpreterPythonMinor(); err != nil {
+			g.logger.Debug("could not detect the Python version, using every standard-library module", "error", err)
+		} else {
+			g.logger.Debug("standard library of detected Python", "version", formatPythonVersion(minor))
+			g.targetPythonMinor = minor
+		}
+	}
+	if g.logger.Enabled(context.Background(), slog.LevelDebug) {
+		g.warnInconsistentSpellings()
+	}
+	g.requirements, g.devRequirements, g.unresolved = g.generateRequirements(installedPackages)
+	if g.since != "" {
+		if g.requirements, err = g.mergeSince(g.requirements, installedPackages); err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %v", g.outputFile, err)
+		}
+	}
+	if g.reportUnused {
+		g.unused = g.findUnused(installedPackages)
+	}
+	g.stats.ImportsFound = len(g.foundModules)
+	g.stats.Unresolved = len(g.unresolved)
+	g.stats.Requirements = len(g.requirements) + len(g.devRequirements)
+
+	if g.generateHashes {
+		all := append(append([]string{}, g.requirements...), g.devRequirements...)
+		if g.hashes, err = g.collectHashes(all); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.requirements, nil
+}
+
+// Requirements returns the requirement lines produced by the last Scan.
+func (g *Generator) Requirements() []string {
+	return g.requirements
+}
+
+// DevRequirements returns the requirement lines of packages only imported by
+// test files, as found by the last Scan. It is empty unless a DevOutputFile
+// is configured.
+func (g *Generator) DevRequirements() []string {
+	return g.devRequirements
+}
+
+// TargetDirs returns the directories scanned by Scan.
+func (g *Generator) TargetDirs() []string {
+	return g.targetDirs
+}
+
+// ModuleSources maps every imported top-level module to the sorted list of
+// files that import it, as found by the last Scan.
+func (g *Generator) ModuleSources() map[string][]string {
+	sources := make(map[string][]string, len(g.moduleSources))
+	for module, files := range g.moduleSources {
+		sources[module] = append([]string{}, files...)
+	}
+	return sources
+}
+
+// ImportSites maps every imported top-level module to the places that import
+// it, as found by the last Scan.
+func (g *Generator) ImportSites() map[string][]ImportSite {
+	sites := make(map[string][]ImportSite, len(g.importSites))
+	for module, moduleSites := range g.importSites {
+		sites[module] = append([]ImportSite{}, moduleSites...)
+	}
+	return sites
+}
+
+// Unresolved returns the third-party modules imported by the project that no
+// installed package provides, as found by the last Scan.
+func (g *Generator) Unresolved() []string {
+	return g.unresolved
+}
+
+// Unused returns the installed distributions that no import of the scanned
+// code resolves to and that no other installed package requires, as found
+// by the last Scan with ReportUnused. They are candidates for removal from
+// the environment.
+func (g *Generator) Unused() []string {
+	return g.unused
+}
+
+// PythonVersion returns the oldest Python version, such as "3.9", able to run
+// the scanned code, inferred from its syntax and standard-library imports by
+// the last Scan. It returns "" when nothing points past Python 3.6.
+func (g *Generator) PythonVersion() string {
+	return formatPythonVersion(g.pythonMinor)
+}
+
+// Virtualenv returns the virtual environment whose packages are used, or ""
+// when none was detected.
+func (g *Generator) Virtualenv() string {
+	return g.venvPath
+}
+
+// OutputFile returns the path of the file written by Write.
+func (g *Generator) OutputFile() string {
+	return g.outputFile
+}
+
+// DevOutputFile returns the path of the dev requirements file written by
+// Write, or "" when dev requirements are not separated.
+func (g *Generator) DevOutputFile() string {
+	return g.devOutputFile
+}
+
+// Write stores the requirement lines produced by the last Scan in the output
+// file, and the dev requirements in the dev output file when configured. A
+// file whose requirement set is empty is left untouched unless AllowEmpty is
+// set, so a hand-maintained file is never clobbered by an empty scan.
+func (g *Generator) Write() error {
+	if len(g.requirements) > 0 || g.allowEmpty {
+		if err := g.writeOutput(); err != nil {
+			return err
+		}
+	}
+	if g.devOutputFile != "" && (len(g.devRequirements) > 0 || g.allowEmpty) {
+		return g.writeRequirements(g.devOutputFile, g.devRequirements, nil)
+	}
+	return nil
+}
+
+func (g *Generator) writeOutput() error {
+	if g.template != nil {
+		return g.writeTemplate()
+	}
+	switch g.format {
+	case FormatPyproject:
+		return g.writePyproject(pep508Requirements(g.requirements))
+	case FormatJSON:
+		return g.writeJSON()
+	case FormatYAML:
+		return g.writeYAML()
+	case FormatSetup:
+		return g.writeSetupPy(pep508Requirements(g.requirements))
+	case FormatSetupCfg:
+		return g.writeSetupCfg(pep508Requirements(g.requirements))
+	case FormatPipfile:
+		return g.writePipfile(g.requirements)
+	case FormatPoetry:
+		return g.writePoetry(pep508Requirements(g.requirements))
+	case FormatConda:
+		return g.writeCondaEnvironment(g.requirements)
+	case FormatCSV:
+		return g.writeCSV()
+	case FormatDot:
+		return g.writeDotFile()
+	default:
+		return g.writeRequirements(g.outputFile, g.outputRequirements(), g.unresolvedLines())
+	}
+}
+
+// skipModule reports whether module is never a requirement: it belongs to
+// the standard library or the project, or is ignored by configuration.
+func (g *Generator) skipModule(module string) bool {
+	switch {
+	case isStandardLibrary(topLevelModule(module), g.targetPythonMinor):
+		g.logger.Debug("standard library, skipped", "module", module)
+		g.stats.Stdlib++
+	case g.localModules[topLevelModule(module)]:
+		g.logger.Debug("local module, skipped", "module", module)
+		g.stats.Local++
+	case g.ignoreModules[pep503Normalize(module)] || g.ignoreModules[pep503Normalize(topLevelModule(module))]:
+		g.logger.Debug("ignored by configuration, skipped", "module", module)
+		g.stats.Ignored++
+	default:
+		return false
+	}
+	return true
+}
+
+// externalModules returns the sorted third-party modules found, for
+// ModulesOnly.
+func (g *Generator) externalModules() []string {
+	var modules []string
+	for _, module := range sortedKeys(g.foundModules) {
+		if !g.skipModule(module) {
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+// generateRequirements matches the found modules against the installed
+// packages. It returns the requirement lines of the matched packages, split
+// into runtime and dev requirements when a dev output file is configured, and
+// the third-party modules that no installed package provides.
+func (g *Generator) generateRequirements(installedPackages map[string]string) (requirements, devRequirements, unresolved []string) {
+	// Normalized package name -> the modules that may be provided by it
+	normalizedFound := make(map[string][]string)
+
+	normalizedInstalled := make(map[string]string)
+	for pkgName := range installedPackages {
+		normalizedInstalled[pep503Normalize(pkgName)] = pkgName
+	}
+
+	// Normalize found module names, resolving known import names to their
+	// distribution names before falling back to the module name itself
+	for _, module := range sortedKeys(g.foundModules) {
+		if g.skipModule(module) {
+			continue
+		}
+		if line, ok := resolveWith(g.resolvers, module, installedPackages); ok {
+			pkgName := pep503Normalize(requirementDistribution(line))
+			normalizedFound[pkgName] = append(normalizedFound[pkgName], module)
+			if installed, ok := normalizedInstalled[pkgName]; ok {
+				g.logger.Debug("matched by custom resolver", "module", module, "package", installedPackages[installed], "imported_by", g.sourcesOf(module))
+				g.stats.Matched++
+			} else {
+				g.logger.Debug("custom resolver names a package that is not installed", "module", module, "line", line, "imported_by", g.sourcesOf(module))
+				unresolved = append(unresolved, module)
+			}
+			continue
+		}
+		if pkgName, ok := g.resolveAmbiguousImport(module, normalizedInstalled); ok {
+			normalizedFound[pkgName] = append(normalizedFound[pkgName], module)
+			if installed, ok := normalizedInstalled[pkgName]; ok {
+				g.logger.Debug("matched", "module", module, "package", installedPackages[installed], "imported_by", g.sourcesOf(module))
+				g.stats.Matched++
+			} else {
+				g.logger.Debug("no installed package", "module", module, "imported_by", g.sourcesOf(module))
+				unresolved = append(unresolved, module)
+			}
+			continue
+		}
+		if line, ok := resolveWith(g.builtinResolvers(), module, installedPackages); ok {
+			pkgName := pep503Normalize(requirementDistribution(line))
+			normalizedFound[pkgName] = append(normalizedFound[pkgName], module)
+			g.logger.Debug("matched via import name mapping", "module", module, "package", line, "imported_by", g.sourcesOf(module))
+			g.stats.Matched++
+			continue
+		}
+
+		// Fall back to a package named like the module
+		normalized := pep503Normalize(module)
+		normalizedFound[normalized] = append(normalizedFound[normalized], module)
+		if installed, ok := normalizedInstalled[normalized]; ok {
+			g.logger.Debug("matched", "module", module, "package", installedPackages[installed], "imported_by", g.sourcesOf(module))
+			g.stats.Matched++
+		} else {
+			g.logger.Debug("no installed package", "module", module, "imported_by", g.sourcesOf(module))
+			unresolved = append(unresolved, module)
+		}
+	}
+
+	g.packageModules = normalizedFound
+
+	// Match installed packages with found modules
+	var packageNames []string
+	dev := make(map[string]bool)
+	for pkgName := range installedPackages {
+		modules, ok := normalizedFound[pep503Normalize(pkgName)]
+		if !ok {
+			continue
+		}
+		packageNames = append(packageNames, pkgName)
+		if g.devOutputFile != "" && g.onlyImportedByTests(modules) {
+			g.logger.Debug("only imported by test files, dev requirement", "package", pkgName)
+			dev[pkgName] = true
+		}
+	}
+	if g.constraints != nil {
+		sort.Strings(packageNames)
+		packageNames = g.filterConstrained(packageNames)
+	}
+	for _, pkgName := range packageNames {
+		g.directPackages[pep503Normalize(pkgName)] = true
+	}
+	// Ignored packages neither become requirements nor pull in dependencies
+	packageNames = g.filterIgnoredPackages(packageNames)
+	if g.withDeps {
+		sort.Strings(packageNames)
+		packageNames = g.filterIgnoredPackages(g.expandDependencies(packageNames, dev, installedPackages))
+	}
+	if g.onlyPackages != nil {
+		packageNames = g.filterOnlyPackages(packageNames)
+	}
+	g.sortPackages(packageNames, func(pkgName string) []string {
+		return normalizedFound[pep503Normalize(pkgName)]
+	})
+
+	overridden := make(map[string]bool)
+	for _, pkgName := range packageNames {
+		installed := installedPackages[pkgName]
+		if !g.keepExtras {
+			installed = stripExtras(installed)
+		}
+		if g.stripLocalVersion {
+			installed = stripLocalVersion(installed)
+		}
+		if version, ok := g.versionOverrides[pep503Normalize(pkgName)]; ok {
+			g.logger.Debug("version overridden by configuration", "package", pkgName, "version", version)
+			installed = requirementDistribution(installed) + "==" + version
+			overridden[pep503Normalize(pkgName)] = true
+		}
+		line := formatRequirement(installed, g.pinStyle)
+		marker := g.markers[pep503Normalize(pkgName)]
+		if declared, ok := g.constraints[pep503Normalize(pkgName)]; ok && !overridden[pep503Normalize(pkgName)] {
+			if requirement, declaredMarker, ok := constrainedRequirement(declared); ok {
+				g.logger.Debug("version specifier from constraints file", "package", pkgName, "requirement", requirement)
+				line = requirement
+				if marker == "" {
+					marker = declaredMarker
+				}
+			}
+		}
+		if marker != "" {
+			line += " ; " + marker
+		}
+		if dev[pkgName] {
+			devRequirements = append(devRequirements, line)
+		} else {
+			requirements = append(requirements, line)
+		}
+	}
+
+	for _, pkgName := range sortedKeys(g.versionOverrides) {
+		if !overridden[pkgName] {
+			g.warn("version override does not match any imported package", "package", pkgName)
+		}
+	}
+
+	return requirements, devRequirements, unresolved
+}
+
+// onlyImportedByTests reports whether every file importing any of modules is
+// a test file.
+func (g *Generator) onlyImportedByTests(modules []string) bool {
+	for _, module := range modules {
+		for _, path := range g.moduleSources[module] {
+			if !g.testFiles[path] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeRequirements writes requirements, merged into the file at path when
+// merging, followed by the unresolved lines.
+func (g *Generator) writeRequirements(path string, requirements, unresolved []string) error {
+	if g.merge {
+		merged, err := mergedRequirements(path, requirements)
+		if err != nil {
+			return err
+		}
+		requirements = merged
+	}
+	requirements = append(requirements[:len(requirements):len(requirements)], unresolved...)
+
+	file, err := createOutputFile(path)
+	if err != nil {
+		return err
+	}
+
+	if g.header {
+		if _, err := io.WriteString(file, g.headerComment()); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := g.writeRequirementLines(file, requirements); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// headerComment returns the comment block written before the requirements.
+func (g *Generator) headerComment() string {
+	version := g.version
+	if version == "" {
+		version = "(unknown version)"
+	}
+	header := fmt.Sprintf("%s %s on %s\n", headerPrefix, version, time.Now().Format(time.RFC3339))
+	if g.command != "" {
+		header += "# Command: " + g.command + "\n"
+	}
+	return header + "# Do not edit manually\n"
+}
+
+// WriteRequirements writes the requirement lines produced by the last Scan to
+// w in the requirements.txt format, regardless of the configured format.
+func (g *Generator) WriteRequirements(w io.Writer) error {
+	return g.writeRequirementLines(w, append(g.outputRequirements(), g.unresolvedLines()...))
+}
+
+// outputRequirements returns the lines of requirements.txt output, grouped
+// by directory with GroupByDir.
+func (g *Generator) outputRequirements() []string {
+	if g.groupByDir {
+		return g.groupedRequirements(g.requirements)
+	}
+	return append([]string{}, g.requirements...)
+}
+
+// unresolvedComment starts the comment written for an unresolved import.
+const unresolvedComment = "# unresolved: "
+
+// unresolvedLines returns the lines listing the unresolved imports of the
+// last Scan with IncludeUnresolved, or nil.
+func (g *Generator) unresolvedLines() []string {
+	if !g.includeUnresolved {
+		return nil
+	}
+	var lines []string
+	for _, module := range g.unresolved {
+		if g.uncommentUnresolved {
+			lines = append(lines, module+"  # unresolved")
+		} else {
+			lines = append(lines, unresolvedComment+module)
+		}
+	}
+	return lines
+}
+
+func (g *Generator) writeRequirementLines(w io.Writer, requirements []string) error {
+	writer := bufio.NewWriter(w)
+	for _, req := range requirements {
+		// Hashes go on backslash-continued lines, as pip-compile writes them
+		line := req
+		for _, hash := range g.hashes[req] {
+			line += " \\\n    --hash=" + hash
+		}
+		fmt.Fprintln(writer, line)
+	}
+
+	return writer.Flush()
+}
+
+// sourcesOf returns the files importing module as a comma-separated list.
+func (g *Generator) sourcesOf(module string) string {
+	return strings.Join(g.moduleSources[module], ", ")
+}
+
+// warnInconsistentSpellings warns about modules imported under spellings
+// that differ only in case or in "-", "_" and "." separators, such as "yaml"
+// and "Yaml", which usually means one of them is a typo.
+func (g *Generator) warnInconsistentSpellings() {
+	spellings := make(map[string][]string)
+	for _, module := range sortedKeys(g.foundModules) {
+		normalized := pep503Normalize(module)
+		spellings[normalized] = append(spellings[normalized], module)
+	}
+
+	for _, normalized := range sortedKeys(spellings) {
+		if modules := spellings[normalized]; len(modules) > 1 {
+			imports := make([]string, 0, len(modules))
+			for _, module := range modules {
+				imports = append(imports, module+" ("+g.sourcesOf(module)+")")
+			}
+			g.warn("module imported under inconsistent spellings", "module", normalized, "imports", imports)
+		}
+	}
+}
+
+// resolveAmbiguousImport returns the normalized distribution name for an
+// import provided by several distributions, preferring the installed
+// candidate and warning when more than one is installed. ok is false when
+// the import is not ambiguous or has a configured mapping.
+func (g *Generator) resolveAmbiguousImport(module string, normalizedInstalled map[string]string) (pkgName string, ok bool) {
+	var candidates []string
+	for importName, pkgNames := range ambiguousImports {
+		if pep503Normalize(importName) == pep503Normalize(module) {
+			candidates = pkgNames
+		}
+	}
+	if candidates == nil {
+		return "", false
+	}
+	for importName := range g.importMappings {
+		if pep503Normalize(importName) == pep503Normalize(module) {
+			return "", false
+		}
+	}
+
+	var installed []string
+	for _, candidate := range candidates {
+		if _, ok := normalizedInstalled[pep503Normalize(candidate)]; ok {
+			installed = append(installed, candidate)
+		}
+	}
+	switch len(installed) {
+	case 0:
+		return pep503Normalize(candidates[0]), true
+	case 1:
+		return pep503Normalize(installed[0]), true
+	}
+	g.warn("import is provided by several installed distributions", "module", module, "chosen", installed[0], "alternatives", installed[1:])
+	return pep503Normalize(installed[0]), true
+}
+
+// warning is a message recorded by warn, with its slog key-value pairs.
+type warning struct {
+	msg  string
+	args []interface{}
+}
+
+// warn records a warning for Warnings and LogWarnings, logging it right away
+// when debug output is enabled. args are slog key-value pairs.
+func (g *Generator) warn(msg string, args ...interface{}) {
+	if g.logger.Enabled(context.Background(), slog.LevelDebug) {
+		g.logger.Warn(msg, args...)
+	}
+	g.warnings = append(g.warnings, warning{msg: msg, args: args})
+}
+
+// Warnings returns the warnings raised by the last Scan, such as files that
+// could not be parsed, in the order they occurred.
+func (g *Generator) Warnings() []string {
+	warnings := make([]string, 0, len(g.warnings))
+	for _, w := range g.warnings {
+		var b strings.Builder
+		b.WriteString(w.msg)
+		for i := 0; i+1 < len(w.args); i += 2 {
+			if i == 0 {
+				b.WriteString(":")
+			}
+			fmt.Fprintf(&b, " %v=%v", w.args[i], w.args[i+1])
+		}
+		warnings = append(warnings, b.String())
+	}
+	return warnings
+}
+
+// LogWarnings logs the warnings raised by the last Scan to the Logger at warn
+// level as one block, a "Warnings (N):" record followed by the warnings in
+// the order they occurred, so they are not lost among the other output.
+// With debug output enabled they were logged as they occurred, and are not
+// logged again.
+func (g *Generator) LogWarnings() {
+	if len(g.warnings) == 0 || g.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	g.logger.Warn(fmt.Sprintf("Warnings (%d):", len(g.warnings)))
+	for _, w := range g.warnings {
+		g.logger.Warn(w.msg, w.args...)
+	}
+}
+
+// filterOnlyPackages keeps the packages named in the OnlyPackages allowlist.
+func (g *Generator) filterOnlyPackages(packageNames []string) []string {
+	var kept []string
+	for _, pkgName := range packageNames {
+		if g.onlyPackages[pep503Normalize(pkgName)] {
+			kept = append(kept, pkgName)
+		} else {
+			g.logger.Debug("not in allowlist, skipped", "package", pkgName)
+		}
+	}
+	return kept
+}
+
+// filterIgnoredPackages drops the packages listed in IgnorePackages.
+func (g *Generator) filterIgnoredPackages(packageNames []string) []string {
+	var kept []string
+	for _, pkgName := range packageNames {
+		if g.ignorePackages[pep503Normalize(pkgName)] {
+			g.logger.Debug("package ignored by configuration, skipped", "package", pkgName)
+		} else {
+			kept = append(kept, pkgName)
+		}
+	}
+	return kept
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stripExtras removes the extras from a requirement line, e.g. turning
+// "requests[security]==2.31.0" into "requests==2.31.0". Direct references
+// are returned unchanged.
+func stripExtras(line string) string {
+	if _, _, _, ok := directReference(line); ok {
+		return line
+	}
+	name := requirementDistribution(line)
+	rest := strings.TrimPrefix(line, name)
+	if !strings.HasPrefix(rest, "[") {
+		return line
+	}
+	if end := strings.Index(rest, "]"); end >= 0 {
+		return name + rest[end+1:]
+	}
+	return line
+}
+
+// requirementExtras returns the extras of a requirement line, e.g.
+// ["security"] for "requests[security]==2.31.0".
+func requirementExtras(line string) []string {
+	if _, _, _, ok := directReference(line); ok {
+		return nil
+	}
+	rest := strings.TrimPrefix(line, requirementDistribution(line))
+	end := strings.Index(rest, "]")
+	if !strings.HasPrefix(rest, "[") || end < 0 {
+		return nil
+	}
+	var extras []string
+	for _, extra := range strings.Split(rest[1:end], ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			extras = append(extras, extra)
+		}
+	}
+	return extras
+}
+
+// pep503Separators matches the runs of separators that PEP 503 collapses.
+var pep503Separators = regexp.MustCompile(`[-_.]+`)
+
+// pep503Normalize folds an import or distribution name as PEP 503 does, so
+// that names differing only in case or in runs of "-", "_" and "." compare
+// equal, e.g. "Jinja2" and "jinja2", "typing_extensions" and
+// "typing-extensions", or "ruamel.yaml" and "ruamel-yaml".
+func pep503Normalize(name string) string {
+	return strings.ToLower(pep503Separators.ReplaceAllString(name, "-"))
+}
@@ -0,0 +1,171 @@
+package pyreqs
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// requirementsFor returns the requirements that the imports of content
+// resolve to among the packages listed in freeze, "pip freeze" output.
+func requirementsFor(t *testing.T, opts Options, freeze, content string) []string {
+	t.Helper()
+	g := newTestGenerator(opts)
+	installed, err := g.parseFreeze([]byte(freeze))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.addModules("app.py", g.extractImportPositions(content))
+	requirements, _, _ := g.generateRequirements(installed)
+	return requirements
+}
+
+func TestRequirementsIgnoreImportCase(t *testing.T) {
+	for _, freeze := range []string{"Flask==2.3.2\n", "flask==2.3.2\n", "FLASK==2.3.2\n"} {
+		for _, module := range []string{"Flask", "flask", "FLASK"} {
+			got := requirementsFor(t, Options{}, freeze, "import "+module+"\n")
+			want := []string{freeze[:len(freeze)-1]}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("import %s with %q installed = %v, want %v", module, freeze, got, want)
+			}
+		}
+	}
+}
+
+func TestRequirementsMixedCaseImportsOnce(t *testing.T) {
+	got := requirementsFor(t, Options{}, "Flask==2.3.2\n", "import flask\nimport Flask\nfrom FLASK import app\n")
+	if want := []string{"Flask==2.3.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+}
+
+func TestPep503Normalize(t *testing.T) {
+	tests := map[string]string{
+		"Jinja2":            "jinja2",
+		"typing_extensions": "typing-extensions",
+		"ruamel.yaml":       "ruamel-yaml",
+		"Ruamel__Yaml":      "ruamel-yaml",
+		"zope.interface":    "zope-interface",
+		"a-_.b":             "a-b",
+	}
+	for name, want := range tests {
+		if got := pep503Normalize(name); got != want {
+			t.Errorf("pep503Normalize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRequirementsMatchNormalizedNames(t *testing.T) {
+	freeze := "Jinja2==3.1.2\ntyping_extensions==4.7.1\nzope.interface==6.0\n"
+	content := "import jinja2\nimport typing_extensions\nimport zope.interface\n"
+	got := requirementsFor(t, Options{}, freeze, content)
+	want := []string{"Jinja2==3.1.2", "typing_extensions==4.7.1", "zope.interface==6.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+}
+
+func TestAmbiguousImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		freeze   string
+		want     []string
+		warnings int
+	}{
+		{"pymongo installed", "pymongo==4.5.0\n", []string{"pymongo==4.5.0"}, 0},
+		{"bson installed", "bson==0.5.10\n", []string{"bson==0.5.10"}, 0},
+		{"both installed", "bson==0.5.10\npymongo==4.5.0\n", []string{"pymongo==4.5.0"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(Options{})
+			installed, err := g.parseFreeze([]byte(tt.freeze))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.addModules("app.py", g.extractImportPositions("import bson\n"))
+			requirements, _, _ := g.generateRequirements(installed)
+			if !reflect.DeepEqual(requirements, tt.want) {
+				t.Errorf("requirements = %v, want %v", requirements, tt.want)
+			}
+			if got := g.Warnings(); len(got) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", got, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestAmbiguousImportMappingWins(t *testing.T) {
+	freeze := "bson==0.5.10\npymongo==4.5.0\n"
+	got := requirementsFor(t, Options{ImportMappings: map[string]string{"bson": "bson"}}, freeze, "import bson\n")
+	if want := []string{"bson==0.5.10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+}
+
+func TestIgnoreModules(t *testing.T) {
+	freeze := "PyYAML==6.0\nrequests==2.31.0\n"
+	content := "import yaml\nimport requests\nimport vendored_lib\n"
+	tests := []struct {
+		name       string
+		opts       Options
+		want       []string
+		unresolved []string
+	}{
+		{"none", Options{}, []string{"PyYAML==6.0", "requests==2.31.0"}, []string{"vendored_lib"}},
+		{"mapped module", Options{IgnoreModules: []string{"yaml"}}, []string{"requests==2.31.0"}, []string{"vendored_lib"}},
+		{"unmapped module", Options{IgnoreModules: []string{"vendored-lib"}}, []string{"PyYAML==6.0", "requests==2.31.0"}, nil},
+		{"package instead of module", Options{IgnorePackages: []string{"pyyaml"}}, []string{"requests==2.31.0"}, []string{"vendored_lib"}},
+		{"module name as package", Options{IgnorePackages: []string{"yaml"}}, []string{"PyYAML==6.0", "requests==2.31.0"}, []string{"vendored_lib"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(tt.opts)
+			installed, err := g.parseFreeze([]byte(freeze))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.addModules("app.py", g.extractImportPositions(content))
+			requirements, _, unresolved := g.generateRequirements(installed)
+			if !reflect.DeepEqual(requirements, tt.want) {
+				t.Errorf("requirements = %v, want %v", requirements, tt.want)
+			}
+			if !reflect.DeepEqual(unresolved, tt.unresolved) {
+				t.Errorf("unresolved = %v, want %v", unresolved, tt.unresolved)
+			}
+		})
+	}
+}
+
+func TestLogWarnings(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		// Records logged as the warning occurs and by LogWarnings
+		during, after int
+	}{
+		{slog.LevelWarn, 0, 1},
+		{slog.LevelError, 0, 0},
+		{slog.LevelDebug, 1, 0},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		g := newTestGenerator(Options{Logger: slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: tt.level}))})
+		g.warn("could not parse file", "file", "app.py")
+		g.warn("could not parse file", "file", "lib.py")
+		if got := strings.Count(b.String(), `"msg":"could not parse file"`); got != 2*tt.during {
+			t.Errorf("level %v: %d records while scanning, want %d", tt.level, got, tt.during)
+		}
+		b.Reset()
+		g.LogWarnings()
+		for _, record := range []string{`"msg":"Warnings (2):"`, `"msg":"could not parse file","file":"app.py"`, `"msg":"could not parse file","file":"lib.py"`} {
+			if got := strings.Count(b.String(), `"level":"WARN",`+record); got != tt.after {
+				t.Errorf("level %v: LogWarnings logged %q, want %d of %s", tt.level, b.String(), tt.after, record)
+			}
+		}
+		if want := []string{"could not parse file: file=app.py", "could not parse file: file=lib.py"}; !reflect.DeepEqual(g.Warnings(), want) {
+			t.Errorf("Warnings() = %q, want %q", g.Warnings(), want)
+		}
+	}
+}
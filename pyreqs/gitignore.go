@@ -0,0 +1,172 @@
+package pyreqs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitignoreRule is one pattern line of a .gitignore file.
+type gitignoreRule struct {
+	base    string // absolute directory holding the .gitignore
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	// anchored rules match the path relative to base; the others match
+	// the base name at any depth
+	anchored bool
+}
+
+// gitignore is a minimal matcher for .gitignore files supporting comments,
+// negation, directory-only, anchored and "**" patterns.
+type gitignore struct {
+	rules []gitignoreRule
+}
+
+// newGitignore returns a matcher preloaded with the .gitignore files of the
+// directories above dir, up to the root of the enclosing git repository.
+func newGitignore(dir string) *gitignore {
+	gi := &gitignore{}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return gi
+	}
+
+	var ancestors []string
+	for current := abs; !isGitRoot(current); {
+		parent := filepath.Dir(current)
+		if parent == current {
+			return gi // not inside a repository
+		}
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+
+	// Load outermost first so that deeper files take precedence
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		gi.load(ancestors[i])
+	}
+	return gi
+}
+
+func isGitRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// load appends the rules of the .gitignore file in dir, if any.
+func (gi *gitignore) load(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+
+	file, err := os.Open(filepath.Join(abs, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := gitignoreRule{base: abs}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// A slash anywhere but at the end anchors the pattern to base
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		regex, err := regexp.Compile("^" + globToRegex(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.regex = regex
+		gi.rules = append(gi.rules, rule)
+	}
+}
+
+// match reports whether path is ignored. The last matching rule wins.
+func (gi *gitignore) match(path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	ignored := false
+	for _, rule := range gi.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, abs)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		subject := rel
+		if !rule.anchored {
+			subject = rel[strings.LastIndex(rel, "/")+1:]
+		}
+		if rule.regex.MatchString(subject) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegex translates a gitignore glob into a regular expression.
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**"):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
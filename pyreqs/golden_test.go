@@ -0,0 +1,61 @@
+package pyreqs
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the testdata .expected files")
+
+// TestGolden scans each testdata/*.py file against the packages listed in
+// testdata/freeze.txt and compares the requirements to the matching
+// .expected file. Run "go test -update" to regenerate the expected files.
+func TestGolden(t *testing.T) {
+	freeze, err := os.ReadFile(filepath.Join("testdata", "freeze.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join("testdata", "*.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata/*.py fixtures")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".py")
+		t.Run(name, func(t *testing.T) {
+			g := newTestGenerator(Options{})
+			installed, err := g.parseFreeze(freeze)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := readPythonSource(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.addModules(file, g.extractImportPositions(content))
+			requirements, _, _ := g.generateRequirements(installed)
+
+			got := strings.Join(requirements, "\n") + "\n"
+			expectedPath := strings.TrimSuffix(file, ".py") + ".expected"
+			if *update {
+				if err := os.WriteFile(expectedPath, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("requirements for %s:\n%s\nwant:\n%s", file, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package pyreqs
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Headers of the requirement groups that are not a directory.
+const (
+	commonGroup = "common"
+	otherGroup  = "other"
+)
+
+// groupedRequirements arranges requirements into "# from dir/" sections,
+// one per directory GroupDepth levels below a target that holds importing
+// files, in directory order. A requirement imported from several directories
+// is listed in each, or once in a leading "# common" section with
+// GroupCommon. Requirements no scanned file imports directly, such as
+// dependencies, close the list under "# other".
+func (g *Generator) groupedRequirements(requirements []string) []string {
+	groups := make(map[string][]string)
+	for _, req := range requirements {
+		dirs := g.requirementDirs(req)
+		switch {
+		case len(dirs) == 0:
+			groups[otherGroup] = append(groups[otherGroup], req)
+		case len(dirs) > 1 && g.groupCommon:
+			groups[commonGroup] = append(groups[commonGroup], req)
+		default:
+			for _, dir := range dirs {
+				groups[dir] = append(groups[dir], req)
+			}
+		}
+	}
+
+	var names []string
+	for name := range groups {
+		if name != commonGroup && name != otherGroup {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := groups[commonGroup]; ok {
+		names = append([]string{commonGroup}, names...)
+	}
+	if _, ok := groups[otherGroup]; ok {
+		names = append(names, otherGroup)
+	}
+
+	var lines []string
+	for i, name := range names {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		if name == commonGroup || name == otherGroup {
+			lines = append(lines, "# "+name)
+		} else {
+			lines = append(lines, "# from "+name)
+		}
+		lines = append(lines, groups[name]...)
+	}
+	return lines
+}
+
+// requirementDirs returns the sorted group directories of the files that
+// import a module of the requirement's package.
+func (g *Generator) requirementDirs(req string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, module := range g.packageModules[requirementName(req)] {
+		for _, path := range g.moduleSources[module] {
+			if dir := g.groupDir(path); !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// groupDir returns the group of a scanned file: its directory, cut to
+// groupDepth levels below the target that contains it, with a trailing
+// slash, e.g. "services/api/".
+func (g *Generator) groupDir(path string) string {
+	for _, target := range g.targetDirs {
+		rel, err := filepath.Rel(target, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+		if parts[0] == "." {
+			parts = nil
+		}
+		if len(parts) > g.groupDepth {
+			parts = parts[:g.groupDepth]
+		}
+		return filepath.ToSlash(filepath.Join(append([]string{target}, parts...)...)) + "/"
+	}
+	return filepath.ToSlash(filepath.Dir(path)) + "/"
+}
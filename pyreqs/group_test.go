@@ -0,0 +1,66 @@
+package pyreqs
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroupByDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"services/api/app.py":       "import flask\nimport requests\n",
+		"services/api/v1/routes.py": "import six\n",
+		"services/worker/jobs.py":   "import numpy\nimport requests\n",
+	})
+	freeze := "Flask==2.3.2\nnumpy==1.24.3\nrequests==2.31.0\nsix==1.16.0\n"
+	root := filepath.ToSlash(dir)
+
+	tests := []struct {
+		name   string
+		common bool
+		want   []string
+	}{
+		{"each group", false, []string{
+			"# from " + root + "/services/api/",
+			"Flask==2.3.2",
+			"requests==2.31.0",
+			"six==1.16.0",
+			"",
+			"# from " + root + "/services/worker/",
+			"numpy==1.24.3",
+			"requests==2.31.0",
+		}},
+		{"common group", true, []string{
+			"# common",
+			"requests==2.31.0",
+			"",
+			"# from " + root + "/services/api/",
+			"Flask==2.3.2",
+			"six==1.16.0",
+			"",
+			"# from " + root + "/services/worker/",
+			"numpy==1.24.3",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := scanWithFreeze(t, Options{TargetDir: dir, GroupByDir: true, GroupDepth: 2, GroupCommon: tt.common}, freeze)
+			var b strings.Builder
+			if err := g.WriteRequirements(&b); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("grouped requirements =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestGroupByDirOther(t *testing.T) {
+	g := newTestGenerator(Options{GroupByDir: true})
+	if got, want := g.groupedRequirements([]string{"idna==3.4"}), []string{"# other", "idna==3.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groupedRequirements = %q, want %q", got, want)
+	}
+}
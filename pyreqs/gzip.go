@@ -0,0 +1,61 @@
+package pyreqs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// gzipSuffix marks output files that are written gzip-compressed.
+const gzipSuffix = ".gz"
+
+// gzipFile is a gzip stream writing to a file; closing it flushes the stream
+// and then closes the file.
+type gzipFile struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	err := f.Writer.Close()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// createOutputFile creates the file at path, compressing what is written to
+// it with gzip when path ends in ".gz". Close must be checked, since it
+// writes the end of the gzip stream.
+func createOutputFile(path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipSuffix) {
+		return file, nil
+	}
+	return &gzipFile{Writer: gzip.NewWriter(file), file: file}, nil
+}
+
+// readOutputFile returns the contents of the file at path, decompressing it
+// when path ends in ".gz".
+func readOutputFile(path string) ([]byte, error) {
+	if !strings.HasSuffix(path, gzipSuffix) {
+		return os.ReadFile(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
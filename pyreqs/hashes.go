@@ -0,0 +1,78 @@
+package pyreqs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pypiReleaseURL is the PyPI JSON API endpoint describing one release.
+var pypiReleaseURL = "https://pypi.org/pypi/%s/%s/json"
+
+var pypiClient = &http.Client{Timeout: 30 * time.Second}
+
+// hashesFor returns the sha256 digests of every distribution file published
+// on PyPI for pkg at version, formatted as "sha256:<hex>".
+func hashesFor(pkg, version string) ([]string, error) {
+	resp, err := pypiClient.Get(fmt.Sprintf(pypiReleaseURL, url.PathEscape(pkg), url.PathEscape(version)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PyPI returned %s", resp.Status)
+	}
+
+	var release struct {
+		URLs []struct {
+			Digests struct {
+				SHA256 string `json:"sha256"`
+			} `json:"digests"`
+		} `json:"urls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+
+	var hashes []string
+	for _, file := range release.URLs {
+		if file.Digests.SHA256 != "" {
+			hashes = append(hashes, "sha256:"+file.Digests.SHA256)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("no sha256 digests published")
+	}
+
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// collectHashes looks up the hashes of every exactly pinned requirement.
+// pip's hash-checking mode only accepts "==" pins, so other lines are skipped.
+// Extras and markers are not part of the release looked up, so
+// "requests[socks]==2.31.0 ; python_version >= '3.8'" is fetched as
+// requests 2.31.0.
+func (g *Generator) collectHashes(requirements []string) (map[string][]string, error) {
+	hashes := make(map[string][]string)
+	for _, req := range requirements {
+		pinned, _ := splitMarker(req)
+		parts := strings.SplitN(stripExtras(pinned), "==", 2)
+		if len(parts) != 2 {
+			g.warn("not generating hashes: requires an exact pin", "requirement", req)
+			continue
+		}
+
+		digests, err := hashesFor(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch hashes for '%s': %v", req, err)
+		}
+		hashes[req] = digests
+	}
+	return hashes, nil
+}
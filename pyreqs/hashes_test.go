@@ -0,0 +1,69 @@
+package pyreqs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// servePyPI points pypiReleaseURL at a fake PyPI for the test, which records
+// the requested paths and answers for the known releases.
+func servePyPI(t *testing.T, releases map[string]string) *[]string {
+	t.Helper()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		digest, ok := releases[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"urls": [{"digests": {"sha256": %q}}]}`, digest)
+	}))
+	t.Cleanup(server.Close)
+
+	original := pypiReleaseURL
+	pypiReleaseURL = server.URL + "/pypi/%s/%s/json"
+	t.Cleanup(func() { pypiReleaseURL = original })
+	return &paths
+}
+
+func TestCollectHashes(t *testing.T) {
+	paths := servePyPI(t, map[string]string{
+		"/pypi/requests/2.31.0/json": "aaa",
+		"/pypi/pywin32/306/json":     "bbb",
+	})
+	requirements := []string{
+		"requests[socks,security]==2.31.0",
+		`pywin32==306 ; sys_platform == "win32"`,
+		"six>=1.16",
+	}
+
+	g := newTestGenerator(Options{})
+	hashes, err := g.collectHashes(requirements)
+	if err != nil {
+		t.Fatalf("collectHashes: %v", err)
+	}
+	want := map[string][]string{
+		"requests[socks,security]==2.31.0":       {"sha256:aaa"},
+		`pywin32==306 ; sys_platform == "win32"`: {"sha256:bbb"},
+	}
+	if !reflect.DeepEqual(hashes, want) {
+		t.Errorf("hashes = %v, want %v", hashes, want)
+	}
+	if want := []string{"/pypi/requests/2.31.0/json", "/pypi/pywin32/306/json"}; !reflect.DeepEqual(*paths, want) {
+		t.Errorf("requested %v, want %v", *paths, want)
+	}
+	if len(g.Warnings()) != 1 {
+		t.Errorf("warnings = %q, want one for the unpinned six", g.Warnings())
+	}
+}
+
+func TestCollectHashesUnknownRelease(t *testing.T) {
+	servePyPI(t, nil)
+	if _, err := newTestGenerator(Options{}).collectHashes([]string{"requests==0.0.0"}); err == nil {
+		t.Error("collectHashes succeeded for a release PyPI does not know")
+	}
+}
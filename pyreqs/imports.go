@@ -0,0 +1,480 @@
+package pyreqs
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Import is a module imported by Python source code and the 1-based number
+// of the line its import statement starts on.
+type Import struct {
+	Name string
+	Line int
+}
+
+// importNames returns the module names of imports, in order.
+func importNames(imports []Import) []string {
+	var names []string
+	for _, imp := range imports {
+		names = append(names, imp.Name)
+	}
+	return names
+}
+
+// Regex patterns for Python imports, matched against one statement at a time
+// (see matchImportPositions). Leading indentation is allowed so that imports
+// inside functions, classes and try/except blocks are detected. Nothing needs
+// to follow the import keyword of a from-import, so "from x import*" and
+// "from x import(a, b)" match.
+var (
+	importRegex        = regexp.MustCompile(`^[ \t]*import[ \t]+(.+)`)
+	fromImportRegex    = regexp.MustCompile(`^[ \t]*from[ \t]+(\.*)[ \t]*([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)?[ \t]+import`)
+	identifierRegex    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	directiveRegex     = regexp.MustCompile(`#\s*pyreqs:\s*(ignore-file|ignore)\b`)
+	typeCheckingRegex  = regexp.MustCompile(`^([ \t]*)if\s+(?:typing\.)?TYPE_CHECKING\s*:`)
+	doctestImportRegex = regexp.MustCompile(`(?m)^[ \t]*>>>[ \t]+((?:import|from)\s[^\n]*)`)
+	dynamicImportRegex = regexp.MustCompile(`(?:\bimportlib\.import_module|\b__import__)\(\s*['"]([\w.]+)['"]`)
+)
+
+// ignoredImports are modules never recorded as imports: "from __future__
+// import" statements are compiler directives rather than dependencies.
+var ignoredImports = map[string]bool{
+	"__future__": true,
+}
+
+// moduleName returns the name an imported module path is matched by: its
+// top-level package (e.g. "requests" for "requests.auth"), or, below a
+// namespace root, as many leading components as its namespace depth (e.g.
+// "google.cloud.storage" for "google.cloud.storage.blob").
+func (g *Generator) moduleName(path string) string {
+	parts := strings.Split(path, ".")
+	depth := g.namespaceDepth(parts)
+	if depth > len(parts) {
+		depth = len(parts)
+	}
+	return strings.Join(parts[:depth], ".")
+}
+
+// namespaceDepth returns how many leading components of the module path parts
+// identify a distribution. The longest namespace root parts starts with
+// decides, so "google.cloud" can be deeper than "google"; paths outside every
+// namespace root have a depth of 1.
+func (g *Generator) namespaceDepth(parts []string) int {
+	for n := len(parts); n > 0; n-- {
+		if depth, ok := g.namespaceDepths[strings.Join(parts[:n], ".")]; ok {
+			return max(depth, 1)
+		}
+	}
+	return 1
+}
+
+// fromImportModules returns the names "from module import names" is matched
+// by. When module is shorter than its namespace depth, as in "from
+// google.cloud import storage", the imported names are the subpackages that
+// identify the distributions, so each of them is matched; a star import
+// falls back to module itself.
+func (g *Generator) fromImportModules(module string, names []string) []string {
+	parts := strings.Split(module, ".")
+	if len(names) == 0 || g.namespaceDepth(parts) <= len(parts) {
+		return []string{g.moduleName(module)}
+	}
+	var modules []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == "*" {
+			return []string{g.moduleName(module)}
+		}
+		if m := g.moduleName(module + "." + name); !seen[m] {
+			seen[m] = true
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+// topLevelModule returns the first component of a module name.
+func topLevelModule(module string) string {
+	return strings.Split(module, ".")[0]
+}
+
+// utf8BOM is the byte order mark some Windows editors put at the start of
+// UTF-8 files.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// readPythonSource returns the contents of a Python source file.
+func readPythonSource(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return normalizeSource(content), nil
+}
+
+// normalizeSource strips a leading UTF-8 byte order mark, which would keep
+// an import on the first line from matching, and converts CRLF line endings
+// to LF.
+func normalizeSource(content []byte) string {
+	content = bytes.TrimPrefix(content, utf8BOM)
+	return strings.ReplaceAll(string(content), "\r\n", "\n")
+}
+
+func (g *Generator) extractImportsFromPythonCode(content string) []string {
+	return importNames(g.extractImportPositions(content))
+}
+
+// extractImportPositions is extractImportsFromPythonCode with the line of
+// every import.
+func (g *Generator) extractImportPositions(content string) []Import {
+	// Honor "# pyreqs:" directives before their comments are stripped
+	content, ok := applyDirectives(content)
+	if !ok {
+		return nil
+	}
+
+	// Doctest examples live in docstrings, so take their imports out before
+	// the docstrings are dropped and put them back in place
+	stripped := stripCommentsAndDocstrings(content)
+	if g.scanDoctests {
+		stripped = overlayLines(stripped, doctestImportLines(content))
+	}
+	content = stripped
+
+	// Imports only made for type checkers are not needed at runtime
+	if g.excludeTypeChecking {
+		content = stripTypeCheckingBlocks(content)
+	}
+
+	// Join backslash-continued lines so a statement always sits on one line
+	content = joinContinuedStatements(content)
+
+	return g.matchImportPositions(content)
+}
+
+// matchImportPositions returns the modules imported by the statements of
+// content, which has been stripped of comments and docstrings and has no
+// continued lines, with the line of every import. Lines are split into
+// statements at the semicolons outside string literals and comments, so
+// "import os; import requests" yields both modules while
+// "x = 1  # ; import requests" yields none.
+func (g *Generator) matchImportPositions(content string) []Import {
+	var imports []Import
+	for i, line := range strings.Split(content, "\n") {
+		for _, statement := range strings.Split(stripStringsAndComment(line), ";") {
+			imports = append(imports, g.statementImports(statement, i+1)...)
+		}
+	}
+
+	// Find importlib.import_module("name") and __import__("name") calls with
+	// a literal name; opt-in since any matching string counts
+	if g.dynamicImports {
+		lineOf := lineNumbers(content)
+		for _, match := range dynamicImportRegex.FindAllStringSubmatchIndex(content, -1) {
+			// A leading dot is a relative import_module("..x", package) call
+			if module := content[match[2]:match[3]]; !strings.HasPrefix(module, ".") {
+				imports = append(imports, Import{Name: g.moduleName(module), Line: lineOf(match[0])})
+			}
+		}
+	}
+
+	// Report imports in source order
+	sort.SliceStable(imports, func(i, j int) bool { return imports[i].Line < imports[j].Line })
+	return imports
+}
+
+// statementImports returns the modules imported by statement, a single
+// statement on line without string literals or comments.
+func (g *Generator) statementImports(statement string, line int) []Import {
+	var imports []Import
+
+	// "import module[, module ...]"
+	if match := importRegex.FindStringSubmatch(statement); match != nil {
+		for _, module := range splitImportList(match[1]) {
+			if !ignoredImports[module] {
+				imports = append(imports, Import{Name: g.moduleName(module), Line: line})
+			}
+		}
+		return imports
+	}
+
+	// "from module import ..."; parenthesized name lists only follow the
+	// module on the first line, so they need no special handling. Relative
+	// imports ("from . import x", "from ..pkg import y") always refer to the
+	// project itself
+	match := fromImportRegex.FindStringSubmatchIndex(statement)
+	if match == nil || match[3] > match[2] || match[4] < 0 {
+		return nil
+	}
+	module := statement[match[4]:match[5]]
+	if ignoredImports[module] {
+		return nil
+	}
+	for _, name := range g.fromImportModules(module, fromImportNames(statement[match[1]:])) {
+		imports = append(imports, Import{Name: name, Line: line})
+	}
+	return imports
+}
+
+// fromImportNames returns the names imported by list, the part of a "from
+// module import" statement after "import", without their aliases; "*" stands
+// for a star import.
+func fromImportNames(list string) []string {
+	list = strings.Trim(strings.TrimSpace(list), "()")
+	if strings.TrimSpace(list) == "*" {
+		return []string{"*"}
+	}
+	return splitImportList(list)
+}
+
+// stripStringsAndComment returns a line of code without the contents of its
+// string literals and without its trailing "#" comment, so the semicolons and
+// keywords left are code. Triple-quoted strings must be stripped already; an
+// unterminated literal runs to the end of the line.
+func stripStringsAndComment(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch c {
+		case '#':
+			return b.String()
+		case '"', '\'':
+			b.WriteByte(c)
+			for i++; i < len(line) && line[i] != c; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(line) {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// lineNumbers returns a function mapping a byte offset of content to its
+// 1-based line number.
+func lineNumbers(content string) func(offset int) int {
+	var starts []int
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return func(offset int) int {
+		return sort.SearchInts(starts, offset+1) + 1
+	}
+}
+
+// joinContinuedStatements joins backslash-continued lines so a statement always
+// sits on one line, adding a blank line after the statement for every line
+// joined so later lines keep their numbers.
+func joinContinuedStatements(content string) string {
+	lines := strings.Split(content, "\n")
+	joined := make([]string, 0, len(lines))
+	statement, continued := "", 0
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\\") {
+			statement += strings.TrimSuffix(line, "\\") + " "
+			continued++
+			continue
+		}
+		joined = append(joined, statement+line)
+		for ; continued > 0; continued-- {
+			joined = append(joined, "")
+		}
+		statement = ""
+	}
+	if statement != "" {
+		joined = append(joined, statement)
+	}
+	return strings.Join(joined, "\n")
+}
+
+// doctestImportLines returns content with every line blanked except the
+// import statements of its ">>> import x" and ">>> from x import y" doctest
+// lines, which keep their indentation.
+func doctestImportLines(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if match := doctestImportRegex.FindStringSubmatchIndex(line); match != nil {
+			lines[i] = line[:strings.Index(line, ">>>")] + line[match[2]:match[3]]
+		} else {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// overlayLines replaces the lines of base with the non-blank lines of
+// overlay, which has as many lines.
+func overlayLines(base, overlay string) string {
+	baseLines := strings.Split(base, "\n")
+	for i, line := range strings.Split(overlay, "\n") {
+		if line != "" && i < len(baseLines) {
+			baseLines[i] = line
+		}
+	}
+	return strings.Join(baseLines, "\n")
+}
+
+// applyDirectives blanks out lines marked "# pyreqs: ignore". It returns
+// false when the first line is marked "# pyreqs: ignore-file".
+func applyDirectives(content string) (string, bool) {
+	ignored, ok := ignoredLines(content)
+	if !ok {
+		return "", false
+	}
+
+	lines := strings.Split(content, "\n")
+	for number := range ignored {
+		lines[number-1] = ""
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// ignoredLines returns the 1-based numbers of the lines marked
+// "# pyreqs: ignore". It returns false when the first line is marked
+// "# pyreqs: ignore-file".
+func ignoredLines(content string) (map[int]bool, bool) {
+	lines := strings.Split(content, "\n")
+	if match := directiveRegex.FindStringSubmatch(lines[0]); match != nil && match[1] == "ignore-file" {
+		return nil, false
+	}
+
+	ignored := make(map[int]bool)
+	for i, line := range lines {
+		if match := directiveRegex.FindStringSubmatch(line); match != nil && match[1] == "ignore" {
+			ignored[i+1] = true
+		}
+	}
+	return ignored, true
+}
+
+// stripCommentsAndDocstrings blanks out full-line "#" comments and the bodies
+// of triple-quoted strings, keeping line breaks so line positions are stable.
+func stripCommentsAndDocstrings(content string) string {
+	var b strings.Builder
+	delimiter := "" // closing delimiter of the triple-quoted string we are in
+
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if delimiter == "" && strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		b.WriteString(stripTripleQuoted(line, &delimiter))
+	}
+
+	return b.String()
+}
+
+// stripTypeCheckingBlocks blanks out the bodies of "if TYPE_CHECKING:" and
+// "if typing.TYPE_CHECKING:" blocks, which end at the first non-blank line
+// indented no deeper than the if statement, such as its else branch.
+func stripTypeCheckingBlocks(content string) string {
+	lines := strings.Split(content, "\n")
+	filter := typeCheckingFilter{blockIndent: -1}
+	for i, line := range lines {
+		if filter.skip(line) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// typeCheckingFilter follows "if TYPE_CHECKING:" blocks line by line.
+type typeCheckingFilter struct {
+	blockIndent int // indentation of the enclosing if, or -1 outside a block
+}
+
+// skip reports whether line, the next line of the file, is the if statement
+// or body of a TYPE_CHECKING block. Blank lines never end a block.
+func (f *typeCheckingFilter) skip(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if f.blockIndent >= 0 {
+		if indent > f.blockIndent {
+			return true
+		}
+		f.blockIndent = -1
+	}
+	if match := typeCheckingRegex.FindStringSubmatch(line); match != nil {
+		// The body may also follow the colon on the same line
+		f.blockIndent = len(match[1])
+		return true
+	}
+	return false
+}
+
+// stripTripleQuoted removes the parts of line that fall inside triple-quoted
+// strings. delimiter carries the open string across lines.
+func stripTripleQuoted(line string, delimiter *string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(line); {
+		if *delimiter != "" {
+			end := strings.Index(line[i:], *delimiter)
+			if end < 0 {
+				break
+			}
+			i += end + len(*delimiter)
+			*delimiter = ""
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line[i:], `"""`), strings.HasPrefix(line[i:], "'''"):
+			*delimiter = line[i : i+3]
+			i += 3
+		case line[i] == '"' || line[i] == '\'':
+			// Copy a single-quoted string as-is so quotes inside it are not
+			// mistaken for the start of a docstring
+			end := i + 1
+			for end < len(line) && line[end] != line[i] {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				end = len(line) - 1
+			}
+			b.WriteString(line[i : end+1])
+			i = end + 1
+		case line[i] == '#':
+			b.WriteString(line[i:])
+			i = len(line)
+		default:
+			b.WriteByte(line[i])
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// splitImportList returns the dotted module path of every entry in the
+// comma-separated list of an import statement, dropping "as" aliases, so
+// "import a.b.c as abc, numpy as np," yields "a.b.c" and "numpy". Extra
+// whitespace and empty entries, such as after a trailing comma, are ignored.
+func splitImportList(list string) []string {
+	var modules []string
+	for _, entry := range strings.Split(list, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if topLevel := strings.Split(fields[0], ".")[0]; identifierRegex.MatchString(topLevel) {
+			modules = append(modules, fields[0])
+		}
+	}
+	return modules
+}
@@ -0,0 +1,211 @@
+package pyreqs
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestGenerator returns a Generator for opts that logs nowhere.
+func newTestGenerator(opts Options) *Generator {
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return NewGenerator(opts)
+}
+
+func TestExtractImportsSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"tab indented import", "def f():\n\timport requests\n", []string{"requests"}},
+		{"tab indented from-import", "if True:\n\tfrom flask import Flask\n", []string{"flask"}},
+		{"tab after keyword", "import\trequests\nfrom\tflask\timport\tFlask\n", []string{"requests", "flask"}},
+		{"multiple spaces", "import   requests\nfrom   flask   import   Flask\n", []string{"requests", "flask"}},
+		{"star without space", "from numpy import*\n", []string{"numpy"}},
+		{"parenthesized without space", "from numpy import(array, zeros)\n", []string{"numpy"}},
+		{"semicolon separated", "import os; import requests\n", []string{"os", "requests"}},
+		{"semicolon without space", "import os;from flask import Flask\n", []string{"os", "flask"}},
+		{"import after code and semicolon", "x = 1; import requests\n", []string{"requests"}},
+		{"relative import of the package", "from . import models\n", nil},
+		{"relative import of a module", "from .models import User\n", nil},
+		{"relative import two levels up", "from ..pkg.sub import y\n", nil},
+		{"relative import with spaces", "from  ..  import  utils\n", nil},
+		{"relative import in a parenthesized list", "from ...core import (a,\n    b)\n", nil},
+		{"relative import next to an absolute one", "from . import x; import requests\n", []string{"requests"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestGenerator(Options{}).extractImportsFromPythonCode(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelativeImportsNotFound(t *testing.T) {
+	g := newTestGenerator(Options{})
+	content := "from . import models\nfrom .views import index\nfrom ..pkg.sub import y\n"
+	g.addModules("app/main.py", g.extractImportPositions(content))
+	if len(g.foundModules) != 0 {
+		t.Errorf("foundModules = %v, want none", g.foundModules)
+	}
+}
+
+func TestExtractImportsIgnoresSemicolonsInStringsAndComments(t *testing.T) {
+	tests := []string{
+		"x = 1  # ; import requests\n",
+		"help = 'first do this; import requests and retry'\n",
+		"sql = \"BEGIN; import data FROM x\"\n",
+		"s = 'it\\'s; import requests'\n",
+		"print(\"a;\"); x = '; from flask import Flask'\n",
+	}
+	for _, content := range tests {
+		if got := newTestGenerator(Options{}).extractImportsFromPythonCode(content); len(got) != 0 {
+			t.Errorf("extractImportsFromPythonCode(%q) = %v, want none", content, got)
+		}
+	}
+}
+
+func TestExtractImportsSemicolonAfterString(t *testing.T) {
+	content := "print('a; b'); import requests  # ; import flask\n"
+	want := []string{"requests"}
+	if got := newTestGenerator(Options{}).extractImportsFromPythonCode(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", content, got, want)
+	}
+}
+
+func TestExcludeTypeChecking(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"TYPE_CHECKING block", "from typing import TYPE_CHECKING\nimport requests\n\nif TYPE_CHECKING:\n    import numpy\n", []string{"typing", "requests"}},
+		{"typing.TYPE_CHECKING block", "import typing\nif typing.TYPE_CHECKING:\n    import numpy\n    from pandas import DataFrame\n", []string{"typing"}},
+		{"body on the same line", "if TYPE_CHECKING: import numpy\nimport six\n", []string{"six"}},
+		{"blank line in block", "if TYPE_CHECKING:\n    import numpy\n\n    import pandas\nimport six\n", []string{"six"}},
+		{"else branch", "if TYPE_CHECKING:\n    import numpy\nelse:\n    import six\n", []string{"six"}},
+		{"nested block", "def f():\n    if TYPE_CHECKING:\n        import numpy\n    import six\n", []string{"six"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestGenerator(Options{ExcludeTypeChecking: true}).extractImportsFromPythonCode(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeCheckingImportsKeptByDefault(t *testing.T) {
+	content := "if TYPE_CHECKING:\n    import numpy\n"
+	want := []string{"numpy"}
+	if got := newTestGenerator(Options{}).extractImportsFromPythonCode(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", content, got, want)
+	}
+}
+
+func TestBOMAndCRLF(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"BOM", "\xef\xbb\xbfimport requests\nimport six\n"},
+		{"CRLF", "import requests\r\nimport six\r\n"},
+		{"BOM and CRLF", "\xef\xbb\xbfimport requests\r\nimport six\r\n"},
+		{"BOM and continued line", "\xef\xbb\xbfimport requests, \\\r\n    six\r\n"},
+	}
+	want := []string{"requests", "six"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.py")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			g := newTestGenerator(Options{})
+			source, err := readPythonSource(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.extractImportsFromPythonCode(source); !reflect.DeepEqual(got, want) {
+				t.Errorf("imports of %q = %v, want %v", tt.content, got, want)
+			}
+
+			// Files too large to read at once are streamed line by line
+			imports, _, err := g.extractImportsFromReader(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := importNames(imports); !reflect.DeepEqual(got, want) {
+				t.Errorf("streamed imports of %q = %v, want %v", tt.content, got, want)
+			}
+		})
+	}
+}
+
+func TestFutureImportsIgnored(t *testing.T) {
+	for _, content := range []string{
+		"from __future__ import annotations\n",
+		"from __future__ import annotations, division\n",
+		"import __future__\n",
+		"from  __future__  import  (print_function)\n",
+	} {
+		g := newTestGenerator(Options{})
+		if got := g.extractImportsFromPythonCode(content); len(got) != 0 {
+			t.Errorf("extractImportsFromPythonCode(%q) = %v, want none", content, got)
+		}
+		g.addModules("app.py", g.extractImportPositions(content))
+		if _, _, unresolved := g.generateRequirements(map[string]string{}); len(unresolved) != 0 {
+			t.Errorf("unresolved for %q = %v, want none", content, unresolved)
+		}
+	}
+}
+
+func TestExtractImportsAliasedCommaLists(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"import numpy as np, pandas as pd\n", []string{"numpy", "pandas"}},
+		{"import numpy as np, pandas\n", []string{"numpy", "pandas"}},
+		{"import numpy, pandas as pd\n", []string{"numpy", "pandas"}},
+		{"import a as b, c\n", []string{"a", "c"}},
+		{"import a.b.c as abc\n", []string{"a"}},
+		{"import a.b.c as abc, d.e as de\n", []string{"a", "d"}},
+		{"import   numpy   as   np ,   pandas  as  pd\n", []string{"numpy", "pandas"}},
+		{"import\tnumpy\tas\tnp,\tpandas\n", []string{"numpy", "pandas"}},
+		{"import numpy as np,\n", []string{"numpy"}},
+		{"import numpy as np, pandas as pd,\n", []string{"numpy", "pandas"}},
+		{"import numpy as np, \\\n    pandas as pd\n", []string{"numpy", "pandas"}},
+		{"import numpy as np  # , pandas as pd\n", []string{"numpy"}},
+		{"import numpy as np; import pandas as pd\n", []string{"numpy", "pandas"}},
+		{"import os as o, requests as r\n", []string{"os", "requests"}},
+	}
+	for _, tt := range tests {
+		if got := newTestGenerator(Options{}).extractImportsFromPythonCode(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSplitImportList(t *testing.T) {
+	tests := map[string][]string{
+		"numpy as np, pandas as pd": {"numpy", "pandas"},
+		"a.b.c as abc":              {"a.b.c"},
+		" a ,b,, c ,":               {"a", "b", "c"},
+		"1bad, good":                {"good"},
+	}
+	for list, want := range tests {
+		if got := splitImportList(list); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitImportList(%q) = %v, want %v", list, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pyreqs
+
+// importToPackage maps import names to their PyPI distribution names for
+// packages whose import name differs from the name reported by pip freeze.
+var importToPackage = map[string]string{
+	"attr":        "attrs",
+	"bs4":         "beautifulsoup4",
+	"cv2":         "opencv-python",
+	"dateutil":    "python-dateutil",
+	"docx":        "python-docx",
+	"dotenv":      "python-dotenv",
+	"fitz":        "PyMuPDF",
+	"git":         "GitPython",
+	"github":      "PyGithub",
+	"Levenshtein": "python-Levenshtein",
+	"MySQLdb":     "mysqlclient",
+	"OpenSSL":     "pyOpenSSL",
+	"PIL":         "Pillow",
+	"pptx":        "python-pptx",
+	"skimage":     "scikit-image",
+	"sklearn":     "scikit-learn",
+	"slugify":     "python-slugify",
+	"telegram":    "python-telegram-bot",
+	"usb":         "pyusb",
+	"win32api":    "pywin32",
+	"win32con":    "pywin32",
+	"yaml":        "PyYAML",
+	"zmq":         "pyzmq",
+
+	// Namespace packages, named up to their namespace depth
+	"azure.core":                 "azure-core",
+	"azure.identity":             "azure-identity",
+	"azure.storage":              "azure-storage-blob",
+	"google.api_core":            "google-api-core",
+	"google.auth":                "google-auth",
+	"google.cloud.aiplatform":    "google-cloud-aiplatform",
+	"google.cloud.bigquery":      "google-cloud-bigquery",
+	"google.cloud.bigtable":      "google-cloud-bigtable",
+	"google.cloud.datastore":     "google-cloud-datastore",
+	"google.cloud.firestore":     "google-cloud-firestore",
+	"google.cloud.kms":           "google-cloud-kms",
+	"google.cloud.logging":       "google-cloud-logging",
+	"google.cloud.pubsub":        "google-cloud-pubsub",
+	"google.cloud.pubsub_v1":     "google-cloud-pubsub",
+	"google.cloud.secretmanager": "google-cloud-secret-manager",
+	"google.cloud.spanner":       "google-cloud-spanner",
+	"google.cloud.storage":       "google-cloud-storage",
+	"google.cloud.translate":     "google-cloud-translate",
+	"google.cloud.vision":        "google-cloud-vision",
+	"google.oauth2":              "google-auth",
+	"google.protobuf":            "protobuf",
+}
+
+// ambiguousImports maps import names provided by more than one distribution
+// to the candidates, most common first. The installed candidate is used; the
+// first one when several or none are installed.
+var ambiguousImports = map[string][]string{
+	"bson":   {"pymongo", "bson"},
+	"Crypto": {"pycryptodome", "pycrypto"},
+	"jwt":    {"PyJWT", "jwt"},
+	"magic":  {"python-magic", "filemagic"},
+	"serial": {"pyserial", "serial"},
+}
+
+// DefaultNamespaceDepths lists namespace packages whose top-level name is
+// shared by many distributions, with the number of leading components that
+// identify a distribution, e.g. "google.auth" rather than "google". Roots may
+// be dotted: the longest matching root wins, so "google.cloud.storage" is
+// matched whole.
+var DefaultNamespaceDepths = map[string]int{
+	"azure":        2,
+	"google":       2,
+	"google.cloud": 3,
+	"zope":         2,
+}
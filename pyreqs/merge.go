@@ -0,0 +1,139 @@
+package pyreqs
+
+import (
+	"os"
+	"strings"
+)
+
+// mergeRequirements folds generated requirement lines into the lines of an
+// existing requirements file. Comments, blank lines and options such as
+// "-r base.txt" are kept in place. Requirements of packages that are still
+// imported stay where they are: exact "==" pins get the generated version,
+// while hand-written constraints such as ">=2,<3" are kept as written.
+// Requirements of packages no longer imported are dropped, and newly
+// detected packages are appended. Unresolved import comments are dropped,
+// since the current ones are written after the merged lines.
+// Per-requirement options like --hash are not carried over.
+func mergeRequirements(existing, generated []string) []string {
+	generatedByName := make(map[string]string, len(generated))
+	for _, line := range generated {
+		generatedByName[requirementName(line)] = line
+	}
+
+	var merged []string
+	seen := make(map[string]bool)
+	for _, line := range joinContinuedLines(stripHeader(existing)) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, unresolvedComment) {
+			continue
+		}
+		_, _, _, direct := directReference(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") && !direct {
+			merged = append(merged, line)
+			continue
+		}
+
+		name := requirementName(trimmed)
+		update, ok := generatedByName[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		merged = append(merged, mergeRequirement(trimmed, update))
+	}
+
+	for _, line := range generated {
+		if !seen[requirementName(line)] {
+			merged = append(merged, line)
+		}
+	}
+	return merged
+}
+
+// stripHeader drops a generated header comment from the top of lines, since
+// a fresh one is written with the merged requirements.
+func stripHeader(lines []string) []string {
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], headerPrefix) {
+		return lines
+	}
+	lines = lines[1:]
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# Command: ") {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && lines[0] == "# Do not edit manually" {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// mergeRequirement returns the existing requirement line with the version of
+// update if it is an exact pin, keeping its environment marker and inline
+// comment. Other lines are returned as written.
+func mergeRequirement(existing, update string) string {
+	requirement, comment := existing, ""
+	if i := strings.Index(existing, " #"); i >= 0 {
+		requirement = strings.TrimRight(existing[:i], " \t")
+		comment = existing[len(requirement):]
+	}
+	if option := strings.Index(requirement, " --"); option >= 0 {
+		requirement = strings.TrimSpace(requirement[:option])
+	}
+
+	spec, marker := splitMarker(requirement)
+	if !strings.Contains(spec, "==") {
+		return requirement + comment
+	}
+
+	updated, updatedMarker := splitMarker(update)
+	if updatedMarker == "" {
+		updatedMarker = marker
+	}
+	if updatedMarker != "" {
+		updated += " ; " + updatedMarker
+	}
+	return updated + comment
+}
+
+// joinContinuedLines joins backslash-continued lines into one line each.
+func joinContinuedLines(lines []string) []string {
+	var joined []string
+	var continued string
+	for _, line := range lines {
+		if strings.HasSuffix(strings.TrimRight(line, " \t"), "\\") {
+			continued += strings.TrimSuffix(strings.TrimRight(line, " \t"), "\\") + " "
+			continue
+		}
+		joined = append(joined, continued+line)
+		continued = ""
+	}
+	if continued != "" {
+		joined = append(joined, strings.TrimSpace(continued))
+	}
+	return joined
+}
+
+// readLines returns the lines of the file at path, without the line break
+// ending the last one.
+func readLines(path string) ([]string, error) {
+	content, err := readOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// mergedRequirements returns requirements merged into the existing file at
+// path; a missing file leaves requirements unchanged.
+func mergedRequirements(path string, requirements []string) ([]string, error) {
+	existing, err := readLines(path)
+	if os.IsNotExist(err) {
+		return requirements, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return mergeRequirements(existing, requirements), nil
+}
@@ -0,0 +1,56 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeRequirements(t *testing.T) {
+	existing := []string{
+		"# Runtime dependencies of the service.",
+		"# Keep in sync with the Dockerfile.",
+		"",
+		"-r base.txt",
+		"Flask==2.0.0  # web framework",
+		"requests>=2,<3",
+		"six==1.15.0",
+		"pywin32==305 ; sys_platform == \"win32\"",
+	}
+	generated := []string{"Flask==2.3.2", "numpy==1.24.3", "pywin32==306", "requests==2.31.0"}
+	want := []string{
+		"# Runtime dependencies of the service.",
+		"# Keep in sync with the Dockerfile.",
+		"",
+		"-r base.txt",
+		"Flask==2.3.2  # web framework",
+		"requests>=2,<3",
+		"pywin32==306 ; sys_platform == \"win32\"",
+		"numpy==1.24.3",
+	}
+	if got := mergeRequirements(existing, generated); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRequirements =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMergeRequirementsReplacesHeader(t *testing.T) {
+	existing := []string{
+		headerPrefix + " v1.0.0 on 2024-01-01",
+		"# Command: pyreqs .",
+		"# Do not edit manually",
+		"# pinned for production",
+		"requests==2.30.0",
+		unresolvedComment + "acme",
+	}
+	want := []string{"# pinned for production", "requests==2.31.0"}
+	if got := mergeRequirements(existing, []string{"requests==2.31.0"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRequirements = %q, want %q", got, want)
+	}
+}
+
+func TestMergeRequirementsContinuedLines(t *testing.T) {
+	existing := []string{"requests==2.30.0 \\", "    --hash=sha256:abc", "six==1.16.0"}
+	want := []string{"requests==2.31.0", "six==1.16.0"}
+	if got := mergeRequirements(existing, []string{"requests==2.31.0", "six==1.16.0"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRequirements = %q, want %q", got, want)
+	}
+}
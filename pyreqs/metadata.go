@@ -0,0 +1,57 @@
+package pyreqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// packagesDistributionsScript prints the import names provided by every
+// installed distribution, as reported by the environment itself.
+const packagesDistributionsScript = "import importlib.metadata,json;print(json.dumps(importlib.metadata.packages_distributions()))"
+
+// pythonCommand builds an interpreter invocation that is killed when ctx is
+// done: the configured interpreter, the detected virtualenv's, or python3 or
+// python from PATH.
+func (g *Generator) pythonCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	candidates := []string{"python3", "python"}
+	if g.python != "" {
+		candidates = []string{g.python}
+	} else if g.venvPath != "" {
+		candidates = []string{virtualenvPython(g.venvPath)}
+	}
+
+	for _, python := range candidates {
+		if path, err := exec.LookPath(python); err == nil {
+			return exec.CommandContext(ctx, path, args...), nil
+		}
+	}
+	return nil, &ExecutableNotFoundError{Kind: "python interpreter", Name: candidates[0]}
+}
+
+// queryMetadataMappings asks the interpreter's importlib.metadata which
+// distribution provides each top-level import name. Names provided by more
+// than one distribution, such as namespace roots, are left out since they
+// cannot be resolved by name alone.
+func (g *Generator) queryMetadataMappings() (map[string]string, error) {
+	output, err := g.runCommand("importlib.metadata.packages_distributions", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.pythonCommand(ctx, "-c", packagesDistributionsScript)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var distributions map[string][]string
+	if err := json.Unmarshal(output, &distributions); err != nil {
+		return nil, fmt.Errorf("failed to parse importlib.metadata output: %v", err)
+	}
+
+	mappings := make(map[string]string)
+	for importName, dists := range distributions {
+		if len(dists) == 1 {
+			mappings[importName] = dists[0]
+		}
+	}
+	return mappings, nil
+}
@@ -0,0 +1,56 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamespaceImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"from namespace package", "from google.cloud import storage\n", []string{"google.cloud.storage"}},
+		{"several namespace packages", "from google.cloud import storage, bigquery as bq\n", []string{"google.cloud.storage", "google.cloud.bigquery"}},
+		{"parenthesized", "from google.cloud import (pubsub_v1)\n", []string{"google.cloud.pubsub_v1"}},
+		{"star import", "from google.cloud import *\n", []string{"google.cloud"}},
+		{"submodule", "from google.cloud.storage import Client\n", []string{"google.cloud.storage"}},
+		{"dotted import", "import google.cloud.bigquery.job\n", []string{"google.cloud.bigquery"}},
+		{"second-level root", "from google.auth import default\n", []string{"google.auth"}},
+		{"zope", "import zope.interface\n", []string{"zope.interface"}},
+		{"outside namespaces", "from requests import get\n", []string{"requests"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestGenerator(Options{}).extractImportsFromPythonCode(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceDepthsOverride(t *testing.T) {
+	g := newTestGenerator(Options{NamespaceDepths: map[string]int{"google.cloud": 2, "acme": 2}})
+	content := "from google.cloud import storage\nimport acme.tools.cli\n"
+	want := []string{"google.cloud", "acme.tools"}
+	if got := g.extractImportsFromPythonCode(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractImportsFromPythonCode(%q) = %v, want %v", content, got, want)
+	}
+}
+
+func TestNamespaceRequirements(t *testing.T) {
+	g := newTestGenerator(Options{})
+	installed, err := g.parseFreeze([]byte("google-cloud-bigquery==3.11.4\ngoogle-cloud-pubsub==2.18.4\ngoogle-cloud-storage==2.10.0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "from google.cloud import bigquery, storage\nfrom google.cloud import pubsub_v1\n"
+	g.addModules("app.py", g.extractImportPositions(content))
+	requirements, _, _ := g.generateRequirements(installed)
+	want := []string{"google-cloud-bigquery==3.11.4", "google-cloud-pubsub==2.18.4", "google-cloud-storage==2.10.0"}
+	if !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+}
@@ -0,0 +1,62 @@
+package pyreqs
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// notebook is the subset of the Jupyter notebook format needed to read code.
+type notebook struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"`
+	} `json:"cells"`
+}
+
+// readNotebookSource returns the Python code of a notebook's code cells.
+func readNotebookSource(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return notebookSource(bytes.TrimPrefix(content, utf8BOM))
+}
+
+// notebookSource concatenates the code cells of a notebook, dropping IPython
+// magics and shell escapes that are not valid Python.
+func notebookSource(content []byte) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, cell := range nb.Cells {
+		if cell.CellType != "code" {
+			continue
+		}
+
+		// Cell source is either a single string or a list of lines
+		var lines []string
+		if err := json.Unmarshal(cell.Source, &lines); err != nil {
+			var text string
+			if err := json.Unmarshal(cell.Source, &text); err != nil {
+				return "", err
+			}
+			lines = []string{text}
+		}
+
+		for _, line := range strings.Split(strings.Join(lines, ""), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "!") {
+				continue
+			}
+			b.WriteString(strings.TrimSuffix(line, "\r"))
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,172 @@
+package pyreqs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParserBackend selects how imports are extracted from Python source.
+type ParserBackend string
+
+const (
+	// ParserRegex matches import statements with regular expressions. It
+	// needs no interpreter and is the default.
+	ParserRegex ParserBackend = "regex"
+	// ParserAST parses each file with the interpreter's ast module, which
+	// understands every form of import statement. Files the interpreter
+	// cannot parse, and all files when no interpreter is found, fall back to
+	// ParserRegex. It starts one interpreter per file, so it is slower.
+	ParserAST ParserBackend = "ast"
+)
+
+// ParseParserBackend converts a flag value into a ParserBackend.
+func ParseParserBackend(value string) (ParserBackend, error) {
+	switch backend := ParserBackend(value); backend {
+	case ParserRegex, ParserAST:
+		return backend, nil
+	}
+	return "", fmt.Errorf("unknown parser '%s' (want regex or ast)", value)
+}
+
+// Parser extracts the modules imported by Python source code, as the names
+// they are matched by (see moduleName), with the lines importing them.
+type Parser interface {
+	Extract(content string) ([]Import, error)
+}
+
+// regexParser is the ParserRegex implementation.
+type regexParser struct {
+	g *Generator
+}
+
+func (p regexParser) Extract(content string) ([]Import, error) {
+	return p.g.extractImportPositions(content), nil
+}
+
+// astImportsScript prints "line<TAB>module" for every absolute import of the
+// source read from stdin, followed by "<TAB>name,name..." with the imported
+// names for "from module import" statements. With --exclude-type-checking
+// the bodies of "if TYPE_CHECKING:" blocks are skipped, and with
+// --dynamic-imports literal importlib.import_module() and __import__() calls
+// are reported too.
+const astImportsScript = `import ast, sys
+type_checking = "--exclude-type-checking" in sys.argv
+dynamic = "--dynamic-imports" in sys.argv
+
+def is_type_checking(test):
+    if isinstance(test, ast.Name):
+        return test.id == "TYPE_CHECKING"
+    return isinstance(test, ast.Attribute) and test.attr == "TYPE_CHECKING" and isinstance(test.value, ast.Name) and test.value.id == "typing"
+
+def is_dynamic_import(func):
+    if isinstance(func, ast.Name):
+        return func.id == "__import__"
+    return isinstance(func, ast.Attribute) and func.attr == "import_module" and isinstance(func.value, ast.Name) and func.value.id == "importlib"
+
+def walk(node):
+    if isinstance(node, ast.Import):
+        for alias in node.names:
+            print(node.lineno, alias.name, sep="\t")
+    elif isinstance(node, ast.ImportFrom):
+        if node.level == 0 and node.module:
+            print(node.lineno, node.module, ",".join(alias.name for alias in node.names), sep="\t")
+    elif dynamic and isinstance(node, ast.Call) and is_dynamic_import(node.func) and node.args:
+        arg = node.args[0]
+        if isinstance(arg, ast.Constant) and isinstance(arg.value, str) and arg.value and not arg.value.startswith("."):
+            print(node.lineno, arg.value, sep="\t")
+    if type_checking and isinstance(node, ast.If) and is_type_checking(node.test):
+        for child in node.orelse:
+            walk(child)
+        return
+    for child in ast.iter_child_nodes(node):
+        walk(child)
+
+walk(ast.parse(sys.stdin.buffer.read()))
+`
+
+// astParser is the ParserAST implementation.
+type astParser struct {
+	g *Generator
+}
+
+func (p astParser) Extract(content string) ([]Import, error) {
+	ignored, ok := ignoredLines(content)
+	if !ok {
+		return nil, nil
+	}
+
+	args := []string{"-c", astImportsScript}
+	if p.g.excludeTypeChecking {
+		args = append(args, "--exclude-type-checking")
+	}
+	if p.g.dynamicImports {
+		args = append(args, "--dynamic-imports")
+	}
+	output, err := p.g.runCommand("python ast", func(ctx context.Context) (*exec.Cmd, error) {
+		cmd, err := p.g.pythonCommand(ctx, args...)
+		if err == nil {
+			cmd.Stdin = strings.NewReader(content)
+		}
+		return cmd, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var imports []Import
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		number, module, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		module, names, isFrom := strings.Cut(module, "\t")
+		lineNumber, err := strconv.Atoi(number)
+		if err != nil || ignored[lineNumber] || ignoredImports[module] {
+			continue
+		}
+		if !isFrom {
+			imports = append(imports, Import{Name: p.g.moduleName(module), Line: lineNumber})
+			continue
+		}
+		for _, name := range p.g.fromImportModules(module, strings.Split(names, ",")) {
+			imports = append(imports, Import{Name: name, Line: lineNumber})
+		}
+	}
+
+	// Doctest examples are strings to the ast module
+	if p.g.scanDoctests {
+		imports = append(imports, p.g.matchImportPositions(doctestImportLines(content))...)
+		sort.SliceStable(imports, func(i, j int) bool { return imports[i].Line < imports[j].Line })
+	}
+	return imports, nil
+}
+
+// selectParser sets the parser used by the next scan. ParserAST needs an
+// interpreter; without one the regex parser is used and a warning raised.
+func (g *Generator) selectParser() {
+	g.parser = regexParser{g}
+	if g.parserBackend != ParserAST {
+		return
+	}
+	if _, err := g.pythonCommand(context.Background()); err != nil {
+		g.warn("no Python interpreter for the ast parser, using the regex parser", "error", err)
+		return
+	}
+	g.parser = astParser{g}
+}
+
+// extractImports runs the configured parser on content, falling back to the
+// regex parser when it fails, e.g. on a Python 2 file the interpreter cannot
+// parse.
+func (g *Generator) extractImports(path, content string) []Import {
+	imports, err := g.parser.Extract(content)
+	if err != nil {
+		g.logger.Debug("parser failed, using the regex parser", "file", path, "error", err)
+		return g.extractImportPositions(content)
+	}
+	return imports
+}
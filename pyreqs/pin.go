@@ -0,0 +1,115 @@
+package pyreqs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// releaseRegex matches the major and minor release segments at the start of a
+// version, e.g. "1" and "4" in "1.4.7" or "1" and "0" in "1.0rc1".
+var releaseRegex = regexp.MustCompile(`^(\d+)(?:\.(\d+))?((?:\.\d+)*)(.*)$`)
+
+// PinStyle controls how the installed version is written for each requirement.
+type PinStyle string
+
+const (
+	// PinExact pins the installed version exactly, e.g. "requests==2.31.0".
+	PinExact PinStyle = "exact"
+	// PinCompatible allows compatible releases, e.g. "requests~=2.31.0".
+	PinCompatible PinStyle = "compatible"
+	// PinMinimum sets the installed version as a floor, e.g. "requests>=2.31.0".
+	PinMinimum PinStyle = "minimum"
+	// PinCompatibleRange allows any later release of the same major
+	// version, from the installed minor version on, e.g.
+	// "requests>=2.31,<3.0".
+	PinCompatibleRange PinStyle = "compatible-range"
+	// PinCaret writes Poetry's caret requirement, e.g. "requests^2.31.0",
+	// which is not valid outside FormatPoetry.
+	PinCaret PinStyle = "caret"
+	// PinNone emits the bare distribution name, e.g. "requests".
+	PinNone PinStyle = "none"
+)
+
+// ParsePinStyle converts a flag value into a PinStyle.
+func ParsePinStyle(value string) (PinStyle, error) {
+	switch style := PinStyle(value); style {
+	case PinExact, PinCompatible, PinMinimum, PinCompatibleRange, PinCaret, PinNone:
+		return style, nil
+	}
+	return "", fmt.Errorf("unknown pin style '%s' (want exact, compatible, minimum, compatible-range, caret or none)", value)
+}
+
+// stripLocalVersion removes the PEP 440 local version label, the "+" and
+// everything after it, from the version of a "name==version" line, e.g.
+// "torch==2.1.0+cu118" becomes "torch==2.1.0". Other lines are returned
+// unchanged.
+func stripLocalVersion(line string) string {
+	parts := strings.SplitN(line, "==", 2)
+	if len(parts) != 2 {
+		return line
+	}
+	if i := strings.IndexByte(parts[1], '+'); i > 0 {
+		return parts[0] + "==" + parts[1][:i]
+	}
+	return line
+}
+
+// formatRequirement rewrites a "name==version" line from pip freeze using the
+// given pin style. Lines without an exact pin are returned unchanged.
+func formatRequirement(line string, style PinStyle) string {
+	parts := strings.SplitN(line, "==", 2)
+	if len(parts) != 2 {
+		return line
+	}
+	name, version := parts[0], parts[1]
+
+	switch style {
+	case PinCompatible:
+		// "~=" needs at least two release segments, so "~=2" is invalid
+		if !strings.Contains(version, ".") {
+			return name + ">=" + version
+		}
+		return name + "~=" + version
+	case PinMinimum:
+		return name + ">=" + version
+	case PinCompatibleRange:
+		return name + compatibleRange(version)
+	case PinCaret:
+		return name + "^" + version
+	case PinNone:
+		return name
+	default:
+		return line
+	}
+}
+
+// compatibleRange returns a ">=MAJOR.MINOR,<NEXTMAJOR.0" specifier for an
+// installed version, e.g. ">=1.4,<2.0" for "1.4.7" and ">=2.0,<3.0" for
+// "2.0". A pre-release of MAJOR.MINOR itself, such as "1.0rc1" or
+// "2.0.0b2", keeps its full version as the floor since "1.0" would exclude
+// it. Versions that do
+// not start with a release number, or have an epoch, get a plain ">=" floor.
+func compatibleRange(version string) string {
+	// A local version label such as "+cu118" does not affect the range
+	release := strings.SplitN(version, "+", 2)[0]
+	match := releaseRegex.FindStringSubmatch(release)
+	if match == nil || strings.Contains(release, "!") {
+		return ">=" + version
+	}
+	major, err := strconv.Atoi(match[1])
+	if err != nil {
+		return ">=" + version
+	}
+	minor := match[2]
+	if minor == "" {
+		minor = "0"
+	}
+
+	floor := match[1] + "." + minor
+	if strings.Trim(match[3], ".0") == "" && match[4] != "" {
+		floor = release
+	}
+	return fmt.Sprintf(">=%s,<%d.0", floor, major+1)
+}
@@ -0,0 +1,101 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompatibleRange(t *testing.T) {
+	tests := map[string]string{
+		"1.4.7":        ">=1.4,<2.0",
+		"2.0":          ">=2.0,<3.0",
+		"2.0.0":        ">=2.0,<3.0",
+		"3":            ">=3.0,<4.0",
+		"0.9.1":        ">=0.9,<1.0",
+		"1.0rc1":       ">=1.0rc1,<2.0",
+		"1.4.0rc1":     ">=1.4.0rc1,<2.0",
+		"2.0.0b2":      ">=2.0.0b2,<3.0",
+		"1.4.1rc1":     ">=1.4,<2.0",
+		"1.10.0":       ">=1.10,<2.0",
+		"2.1.0+cu118":  ">=2.1,<3.0",
+		"1!2.0":        ">=1!2.0",
+		"dev-snapshot": ">=dev-snapshot",
+	}
+	for version, want := range tests {
+		if got := compatibleRange(version); got != want {
+			t.Errorf("compatibleRange(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestFormatRequirement(t *testing.T) {
+	tests := []struct {
+		line  string
+		style PinStyle
+		want  string
+	}{
+		{"requests==2.31.0", PinExact, "requests==2.31.0"},
+		{"requests==2.31.0", PinCompatible, "requests~=2.31.0"},
+		{"requests==2.31.0", PinMinimum, "requests>=2.31.0"},
+		{"requests==2.31.0", PinCompatibleRange, "requests>=2.31,<3.0"},
+		{"requests==2.31.0", PinCaret, "requests^2.31.0"},
+		{"requests==2.31.0", PinNone, "requests"},
+
+		// Pre-release suffixes stay part of the version
+		{"pkg==1.0rc1", PinExact, "pkg==1.0rc1"},
+		{"pkg==1.0rc1", PinCompatible, "pkg~=1.0rc1"},
+		{"pkg==1.0rc1", PinMinimum, "pkg>=1.0rc1"},
+		{"pkg==1.0rc1", PinNone, "pkg"},
+		{"pkg==2.0.0b2", PinExact, "pkg==2.0.0b2"},
+		{"pkg==2.0.0b2", PinCompatible, "pkg~=2.0.0b2"},
+		{"pkg==2.0.0b2", PinMinimum, "pkg>=2.0.0b2"},
+		{"pkg==2.0.0b2", PinNone, "pkg"},
+		{"pkg==1.0.dev3", PinExact, "pkg==1.0.dev3"},
+		{"pkg==1.0.dev3", PinCompatible, "pkg~=1.0.dev3"},
+		{"pkg==1.0.dev3", PinMinimum, "pkg>=1.0.dev3"},
+		{"pkg==1.0.dev3", PinNone, "pkg"},
+		// "~=" needs two release segments
+		{"pkg==2rc1", PinCompatible, "pkg>=2rc1"},
+	}
+	for _, tt := range tests {
+		if got := formatRequirement(tt.line, tt.style); got != tt.want {
+			t.Errorf("formatRequirement(%q, %s) = %q, want %q", tt.line, tt.style, got, tt.want)
+		}
+	}
+	if got := formatRequirement("mypkg @ git+https://host/mypkg.git", PinCompatibleRange); got != "mypkg @ git+https://host/mypkg.git" {
+		t.Errorf("formatRequirement of a direct reference = %q, want it unchanged", got)
+	}
+}
+
+func TestStripLocalVersion(t *testing.T) {
+	tests := map[string]string{
+		"torch==2.1.0+cu118":              "torch==2.1.0",
+		"torchvision==0.16.0+cpu":         "torchvision==0.16.0",
+		"jaxlib==0.4.13+cuda12.cudnn89":   "jaxlib==0.4.13",
+		"requests==2.31.0":                "requests==2.31.0",
+		"mypkg @ file:///src/mypkg+extra": "mypkg @ file:///src/mypkg+extra",
+	}
+	for line, want := range tests {
+		if got := stripLocalVersion(line); got != want {
+			t.Errorf("stripLocalVersion(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestRequirementsStripLocalVersion(t *testing.T) {
+	freeze := "requests==2.31.0\ntorch==2.1.0+cu118\n"
+	content := "import requests\nimport torch\n"
+	tests := []struct {
+		strip bool
+		want  []string
+	}{
+		{false, []string{"requests==2.31.0", "torch==2.1.0+cu118"}},
+		{true, []string{"requests==2.31.0", "torch==2.1.0"}},
+	}
+	for _, tt := range tests {
+		got := requirementsFor(t, Options{StripLocalVersion: tt.strip}, freeze, content)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("requirements with StripLocalVersion %v = %v, want %v", tt.strip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package pyreqs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultPipTimeout bounds how long listing the installed packages may take.
+const DefaultPipTimeout = 30 * time.Second
+
+// pipRetryDelay is the wait before the first retry of a pip command that
+// failed to start; it doubles with every further retry.
+const pipRetryDelay = 500 * time.Millisecond
+
+// Source selects where the list of installed packages comes from.
+type Source string
+
+const (
+	// SourceFreeze parses the output of "pip freeze".
+	SourceFreeze Source = "freeze"
+	// SourceList parses the output of "pip list --format=json", which also
+	// reports editable and VCS installs that "pip freeze" prints as URLs.
+	SourceList Source = "list"
+	// SourceConda parses the output of "conda list --export", for projects
+	// whose packages are managed by conda rather than pip.
+	SourceConda Source = "conda"
+	// SourceMetadata parses the output of "pip freeze" and resolves import
+	// names through the interpreter's importlib.metadata, which knows the
+	// import names of every installed distribution. The built-in mapping
+	// table is used when the interpreter cannot be queried.
+	SourceMetadata Source = "metadata"
+)
+
+// ParseSource converts a flag value into a Source.
+func ParseSource(value string) (Source, error) {
+	switch source := Source(value); source {
+	case SourceFreeze, SourceList, SourceConda, SourceMetadata:
+		return source, nil
+	}
+	return "", fmt.Errorf("unknown package source '%s' (want freeze, list, conda or metadata)", value)
+}
+
+// pipCommand builds a pip invocation, running "<python> -m pip" when an
+// interpreter is configured or a virtualenv was detected, and the pip
+// executable otherwise. The process is killed when ctx is done.
+func (g *Generator) pipCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	python := g.python
+	if python == "" && g.venvPath != "" {
+		python = virtualenvPython(g.venvPath)
+	}
+
+	if python != "" {
+		path, err := exec.LookPath(python)
+		if err != nil {
+			return nil, &ExecutableNotFoundError{Kind: "python interpreter", Name: python}
+		}
+		return exec.CommandContext(ctx, path, append([]string{"-m", "pip"}, args...)...), nil
+	}
+
+	path, err := exec.LookPath(g.pip)
+	if err != nil {
+		return nil, &ExecutableNotFoundError{Kind: "pip executable", Name: g.pip}
+	}
+	return exec.CommandContext(ctx, path, args...), nil
+}
+
+// runCommand runs a pip or conda command built by build under the configured
+// timeout and returns its output. Errors include what the command wrote to
+// stderr, e.g. pip's explanation of why it failed.
+func (g *Generator) runCommand(name string, build func(ctx context.Context) (*exec.Cmd, error)) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.pipTimeout)
+	defer cancel()
+
+	cmd, err := build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Children of a killed pip, such as a shell wrapper's, may keep its
+	// output pipes open; stop waiting for them shortly after the deadline
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("'%s' did not finish within %s", name, g.pipTimeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("failed to run '%s': %w: %s", name, err, message)
+		}
+		return nil, fmt.Errorf("failed to run '%s': %w", name, err)
+	}
+	return output, nil
+}
+
+// getInstalledPackages returns the installed packages keyed by lowercased
+// name, each mapped to its "name==version" line. Results are served from the
+// on-disk cache while it is fresh. A configured freeze file replaces pip.
+func (g *Generator) getInstalledPackages() (map[string]string, error) {
+	if g.freezeFile != "" {
+		content, err := os.ReadFile(g.freezeFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read freeze file: %v", err)
+		}
+		return g.parseFreeze(content)
+	}
+	if g.noCache {
+		return g.listInstalledPackages()
+	}
+
+	key, cacheable := g.packageCacheKey()
+	if cacheable {
+		if cache, ok := readPackageCache(key); ok {
+			// The duplicates were dropped before caching, so warn again
+			for _, duplicate := range cache.Duplicates {
+				g.warnDuplicatePackage(duplicate)
+			}
+			return cache.Packages, nil
+		}
+	}
+
+	start := len(g.duplicatePackages)
+	packages, err := g.listInstalledPackages()
+	if err == nil && cacheable {
+		writePackageCache(key, packageCache{Packages: packages, Duplicates: g.duplicatePackages[start:]})
+	}
+	return packages, err
+}
+
+// duplicatePackage is a line of pip freeze output ignored because an earlier
+// line lists the same package.
+type duplicatePackage struct {
+	Kept    string `json:"kept"`
+	Ignored string `json:"ignored"`
+}
+
+// warnDuplicatePackage warns about and records a duplicate package line.
+func (g *Generator) warnDuplicatePackage(duplicate duplicatePackage) {
+	g.duplicatePackages = append(g.duplicatePackages, duplicate)
+	g.warn("package listed more than once by pip freeze, keeping the first", "kept", duplicate.Kept, "ignored", duplicate.Ignored)
+}
+
+// listInstalledPackages runs pip to list the installed packages, retrying
+// up to PipRetries times with exponential backoff when pip fails to start.
+// A pip that ran and exited with an error is not retried.
+func (g *Generator) listInstalledPackages() (map[string]string, error) {
+	delay := pipRetryDelay
+	for attempt := 1; ; attempt++ {
+		packages, err := g.listInstalledPackagesOnce()
+		if err == nil || attempt > g.pipRetries || !isLaunchError(err) {
+			return packages, err
+		}
+		g.warn("pip failed to start, retrying", "attempt", attempt, "retries", g.pipRetries, "delay", delay, "error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isLaunchError reports whether err is a failure to start a command, such as
+// a "text file busy" executable, rather than its exit status or timeout.
+func isLaunchError(err error) bool {
+	var pathErr *fs.PathError
+	var execErr *exec.Error
+	return errors.As(err, &pathErr) || errors.As(err, &execErr)
+}
+
+// listInstalledPackagesOnce runs pip once to list the installed packages.
+func (g *Generator) listInstalledPackagesOnce() (map[string]string, error) {
+	switch g.source {
+	case SourceList:
+		return g.getInstalledPackagesFromList()
+	case SourceConda:
+		return g.getInstalledPackagesFromConda()
+	}
+
+	output, err := g.runCommand("pip freeze", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.pipCommand(ctx, "freeze")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g.parseFreeze(output)
+}
+
+// parseFreeze reads the installed packages from "pip freeze" output.
+func (g *Generator) parseFreeze(output []byte) (map[string]string, error) {
+	packages := make(map[string]string)
+	// First line seen for each normalized name, to catch duplicates
+	seen := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var name string
+		// Editable and VCS installs keep their install spec, since no
+		// released version of them exists
+		if reference, _, _, ok := directReference(line); ok {
+			name = reference
+		} else if strings.Contains(line, "==") {
+			// Match "name[extra]==version" lines by the bare name
+			name = requirementDistribution(line)
+		} else {
+			continue
+		}
+
+		// Broken environments can list a package twice, e.g. "PyYAML==6.0"
+		// and "pyyaml==5.4"; keep the first so the result does not depend
+		// on which line comes last
+		if first, ok := seen[pep503Normalize(name)]; ok {
+			if first != line {
+				g.warnDuplicatePackage(duplicatePackage{Kept: first, Ignored: line})
+			}
+			continue
+		}
+		seen[pep503Normalize(name)] = line
+		packages[strings.ToLower(name)] = line
+	}
+
+	return packages, scanner.Err()
+}
+
+func (g *Generator) getInstalledPackagesFromList() (map[string]string, error) {
+	output, err := g.runCommand("pip list", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.pipCommand(ctx, "list", "--format=json")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(output, &installed); err != nil {
+		return nil, fmt.Errorf("failed to parse 'pip list' output: %v", err)
+	}
+
+	packages := make(map[string]string)
+	for _, pkg := range installed {
+		packages[strings.ToLower(pkg.Name)] = pkg.Name + "==" + pkg.Version
+	}
+
+	return packages, nil
+}
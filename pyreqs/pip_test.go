@@ -0,0 +1,135 @@
+package pyreqs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFreezeFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.py": "import yaml\nimport requests\nfrom PIL import Image\nimport notinstalled\n"})
+	freezeFile, err := filepath.Abs(filepath.Join("testdata", "freeze.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A pip that cannot run proves it is never invoked
+	g := newTestGenerator(Options{
+		TargetDir:         dir,
+		FreezeFile:        freezeFile,
+		Pip:               filepath.Join(dir, "no-such-pip"),
+		NoVirtualenv:      true,
+		TargetPythonMinor: 11,
+	})
+	requirements, err := g.Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if want := []string{"Pillow==10.0.0", "PyYAML==6.0", "requests==2.31.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+	if want := []string{"notinstalled"}; !reflect.DeepEqual(g.Unresolved(), want) {
+		t.Errorf("unresolved = %v, want %v", g.Unresolved(), want)
+	}
+}
+
+func TestFreezeFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.py": "import requests\n"})
+	g := newTestGenerator(Options{TargetDir: dir, FreezeFile: filepath.Join(dir, "missing.txt"), NoVirtualenv: true, TargetPythonMinor: 11})
+	if _, err := g.Scan(); err == nil || !strings.Contains(err.Error(), "failed to read freeze file") {
+		t.Errorf("Scan() error = %v, want a freeze file error", err)
+	}
+}
+
+func TestParseFreeze(t *testing.T) {
+	g := newTestGenerator(Options{})
+	output := "# comment\nFlask==2.3.2\nrequests[socks]==2.31.0\nPyYAML==6.0\npyyaml==5.4\n" +
+		"-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg\nsetuptools\n"
+	got, err := g.parseFreeze([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"flask":    "Flask==2.3.2",
+		"requests": "requests[socks]==2.31.0",
+		"pyyaml":   "PyYAML==6.0",
+		"mypkg":    "-e git+https://github.com/acme/mypkg.git@1a2b3c#egg=mypkg",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFreeze = %v, want %v", got, want)
+	}
+	if len(g.Warnings()) != 1 {
+		t.Errorf("warnings = %q, want one for the duplicate PyYAML", g.Warnings())
+	}
+}
+
+// logFunc is a slog.Handler passing the message of every record to a function.
+type logFunc func(msg string)
+
+func (f logFunc) Enabled(context.Context, slog.Level) bool { return true }
+func (f logFunc) Handle(_ context.Context, r slog.Record) error {
+	f(r.Message)
+	return nil
+}
+func (f logFunc) WithAttrs([]slog.Attr) slog.Handler { return f }
+func (f logFunc) WithGroup(string) slog.Handler      { return f }
+
+// writeCommand writes an executable file at path with content.
+func writeCommand(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPipRetriesLaunchErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pip")
+	}
+	// Not a valid executable until the first retry is announced
+	pip := filepath.Join(t.TempDir(), "pip")
+	writeCommand(t, pip, "not an executable\n")
+	var retries int
+	logger := slog.New(logFunc(func(msg string) {
+		if msg == "pip failed to start, retrying" {
+			retries++
+			writeCommand(t, pip, "#!/bin/sh\necho requests==2.31.0\n")
+		}
+	}))
+
+	g := newTestGenerator(Options{Pip: pip, PipRetries: 2, NoCache: true, NoVirtualenv: true, Logger: logger})
+	packages, err := g.getInstalledPackages()
+	if err != nil {
+		t.Fatalf("getInstalledPackages: %v", err)
+	}
+	if want := map[string]string{"requests": "requests==2.31.0"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("packages = %v, want %v", packages, want)
+	}
+	if retries != 1 {
+		t.Errorf("retried %d times, want 1", retries)
+	}
+}
+
+func TestPipRetriesSkipExitErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pip")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	pip := filepath.Join(dir, "pip")
+	writeCommand(t, pip, "#!/bin/sh\necho run >> "+calls+"\necho broken environment >&2\nexit 1\n")
+
+	g := newTestGenerator(Options{Pip: pip, PipRetries: 3, NoCache: true, NoVirtualenv: true})
+	if _, err := g.getInstalledPackages(); err == nil || !strings.Contains(err.Error(), "broken environment") {
+		t.Errorf("getInstalledPackages error = %v, want pip's stderr", err)
+	}
+	if content, _ := os.ReadFile(calls); strings.Count(string(content), "run") != 1 {
+		t.Errorf("pip ran %d times, want once", strings.Count(string(content), "run"))
+	}
+}
@@ -0,0 +1,233 @@
+package pyreqs
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var tomlBareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// defaultPipfileSource is written when a new Pipfile is created.
+const defaultPipfileSource = `[[source]]
+url = "https://pypi.org/simple"
+verify_ssl = true
+name = "pypi"`
+
+// writePipfile replaces the [packages] table of the Pipfile, keeping every
+// other table such as [[source]], [dev-packages] and [requires] as is. Like
+// writePyproject it edits the file in place and checks the result parses.
+func (g *Generator) writePipfile(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if os.IsNotExist(err) {
+		content = []byte(defaultPipfileSource + "\n")
+	} else if err != nil {
+		return err
+	}
+
+	var body []string
+	for _, req := range requirements {
+		body = append(body, formatTomlDependency(req, pipfileVersion, pipfileDirectReference))
+	}
+
+	updated := setTomlTable(string(content), "packages", body)
+	if err := checkTomlEdit(g.outputFile, string(content), updated); err != nil {
+		return err
+	}
+	return os.WriteFile(g.outputFile, []byte(updated), 0644)
+}
+
+// pipfileKey quotes a package name unless it is a valid bare TOML key.
+func pipfileKey(name string) string {
+	if tomlBareKeyRegex.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// pipfileDirectReference formats a VCS or file install as a Pipfile inline
+// table, e.g. {git = "https://host/repo.git", ref = "v1", editable = true}.
+func pipfileDirectReference(url string, editable bool) string {
+	var fields []string
+	if strings.HasPrefix(url, "git+") {
+		repository, ref := splitVCSRef(strings.TrimPrefix(url, "git+"))
+		fields = append(fields, "git = "+strconv.Quote(repository))
+		if ref != "" {
+			fields = append(fields, "ref = "+strconv.Quote(ref))
+		}
+	} else {
+		fields = append(fields, "file = "+strconv.Quote(url))
+	}
+	if editable {
+		fields = append(fields, "editable = true")
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// pipfileVersion converts a requirement specifier into a Pipfile version,
+// which is "*" when there is none.
+func pipfileVersion(spec string) string {
+	if spec == "" {
+		return "*"
+	}
+	return spec
+}
+
+// formatTomlDependency formats a requirement line as an entry of a Pipfile
+// or Poetry dependency table. version converts the specifier into the
+// version string and reference formats direct references. Extras and markers
+// turn the entry into an inline table, e.g.
+// requests = {version = "==2.31.0", extras = ["socks"]}.
+func formatTomlDependency(req string, version func(spec string) string, reference func(url string, editable bool) string) string {
+	req, marker := splitMarker(req)
+	if name, url, editable, ok := directReference(req); ok {
+		return pipfileKey(name) + " = " + reference(url, editable)
+	}
+	name := requirementDistribution(req)
+	extras := requirementExtras(req)
+	value := strconv.Quote(version(strings.TrimSpace(stripExtras(req)[len(name):])))
+	if len(extras) == 0 && marker == "" {
+		return pipfileKey(name) + " = " + value
+	}
+
+	fields := []string{"version = " + value}
+	if len(extras) > 0 {
+		quoted := make([]string, len(extras))
+		for i, extra := range extras {
+			quoted[i] = strconv.Quote(extra)
+		}
+		fields = append(fields, "extras = ["+strings.Join(quoted, ", ")+"]")
+	}
+	if marker != "" {
+		fields = append(fields, "markers = "+strconv.Quote(marker))
+	}
+	return pipfileKey(name) + " = {" + strings.Join(fields, ", ") + "}"
+}
+
+// tomlDependency is an entry of a Pipfile or Poetry dependency table.
+// fields holds the string fields of an inline table; a plain string entry
+// is read as its "version".
+type tomlDependency struct {
+	name     string
+	fields   map[string]string
+	extras   []string
+	editable bool
+}
+
+// requirement formats the entry as a requirement line with the given
+// version specifier, or direct reference url when it is not empty.
+func (d tomlDependency) requirement(spec, url string) string {
+	requirement := d.name
+	if len(d.extras) > 0 {
+		requirement += "[" + strings.Join(d.extras, ",") + "]"
+	}
+	switch {
+	case url != "" && d.editable:
+		requirement = "-e " + url + "#egg=" + d.name
+	case url != "":
+		requirement += " @ " + url
+	case spec != "*":
+		requirement += spec
+	}
+	if marker := d.fields["markers"]; marker != "" {
+		requirement += " ; " + marker
+	}
+	return requirement
+}
+
+// readTomlDependencies returns the entries of the dependency table of the
+// TOML file at path, such as the [packages] of a Pipfile, in file order.
+func readTomlDependencies(path, table string) ([]tomlDependency, error) {
+	var document map[string]interface{}
+	meta, err := toml.DecodeFile(path, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := document
+	for _, part := range strings.Split(table, ".") {
+		entries, _ = entries[part].(map[string]interface{})
+	}
+
+	// The decoded tables are maps, so the order comes from the keys
+	var dependencies []tomlDependency
+	depth := strings.Count(table, ".") + 1
+	for _, key := range meta.Keys() {
+		if len(key) != depth+1 || strings.Join(key[:depth], ".") != table {
+			continue
+		}
+		dependency := tomlDependency{name: key[depth], fields: map[string]string{}}
+		switch value := entries[dependency.name].(type) {
+		case string:
+			dependency.fields["version"] = value
+		case map[string]interface{}:
+			// Inline tables such as {version = "==306", markers = "..."}
+			for field, value := range value {
+				switch value := value.(type) {
+				case string:
+					dependency.fields[field] = value
+				case bool:
+					dependency.editable = dependency.editable || field == "editable" && value
+				case []interface{}:
+					if field != "extras" {
+						continue
+					}
+					for _, extra := range value {
+						if extra, ok := extra.(string); ok {
+							dependency.extras = append(dependency.extras, extra)
+						}
+					}
+				}
+			}
+		default:
+			continue
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, nil
+}
+
+// readPipfilePackages returns the [packages] of a Pipfile as requirement lines.
+func readPipfilePackages(path string) ([]string, error) {
+	dependencies, err := readTomlDependencies(path, "packages")
+	if err != nil {
+		return nil, err
+	}
+
+	var requirements []string
+	for _, dependency := range dependencies {
+		url := dependency.fields["file"]
+		if git := dependency.fields["git"]; git != "" {
+			url = "git+" + git
+		}
+		if ref := dependency.fields["ref"]; url != "" && ref != "" {
+			url += "@" + ref
+		}
+		requirements = append(requirements, dependency.requirement(dependency.fields["version"], url))
+	}
+	return requirements, nil
+}
+
+// setTomlTable replaces the body of table with lines, appending the table
+// when it is missing. Blank lines separating it from the next table are kept.
+func setTomlTable(content, table string, body []string) string {
+	lines := strings.Split(content, "\n")
+
+	start, end := tomlTableRange(lines, table)
+	if start < 0 {
+		content = strings.TrimRight(content, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		return content + "[" + table + "]\n" + strings.Join(body, "\n") + "\n"
+	}
+
+	last := end
+	for last > start+1 && strings.TrimSpace(lines[last-1]) == "" {
+		last--
+	}
+	return joinLines(lines[:start+1], body, lines[last:])
+}
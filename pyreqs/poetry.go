@@ -0,0 +1,119 @@
+package pyreqs
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// poetryDependenciesTable is the pyproject.toml table Poetry reads runtime
+// dependencies from.
+const poetryDependenciesTable = "tool.poetry.dependencies"
+
+// poetryPythonRegex matches the line starting the python entry of the
+// dependencies table.
+var poetryPythonRegex = regexp.MustCompile(`^\s*("python"|'python'|python)\s*=`)
+
+// poetryVersionRegex matches a constraint that is a bare version, which
+// Poetry reads as an exact pin.
+var poetryVersionRegex = regexp.MustCompile(`^\d[\w.!+-]*$`)
+
+// writePoetry replaces the [tool.poetry.dependencies] table of the output
+// file, keeping its python entry and every other table, such as [tool.poetry]
+// and [build-system], as is. Like writePyproject it edits the file in place
+// and checks the result parses.
+func (g *Generator) writePoetry(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Poetry requires the python entry, which is not a package. It is kept
+	// with the lines its value continues on
+	var body []string
+	lines := strings.Split(string(content), "\n")
+	if start, end := tomlTableRange(lines, poetryDependenciesTable); start >= 0 {
+		topLevel := tomlTopLevelLines(lines)
+		python := false
+		for i := start + 1; i < end; i++ {
+			if topLevel[i] {
+				python = poetryPythonRegex.MatchString(lines[i])
+			}
+			if python {
+				body = append(body, lines[i])
+			}
+		}
+	}
+	if body == nil && g.PythonVersion() != "" {
+		body = append(body, "python = "+strconv.Quote(">="+g.PythonVersion()))
+	}
+
+	for _, req := range requirements {
+		body = append(body, formatTomlDependency(req, poetryConstraint, poetryDirectReference))
+	}
+
+	updated := setTomlTable(string(content), poetryDependenciesTable, body)
+	if err := checkTomlEdit(g.outputFile, string(content), updated); err != nil {
+		return err
+	}
+	return os.WriteFile(g.outputFile, []byte(updated), 0644)
+}
+
+// poetryConstraint converts a requirement specifier into a Poetry version
+// constraint: "==2.31.0" becomes "2.31.0", no specifier becomes "*", and
+// caret and PEP 440 specifiers are kept as they are.
+func poetryConstraint(spec string) string {
+	switch {
+	case spec == "":
+		return "*"
+	case strings.HasPrefix(spec, "==") && !strings.Contains(spec, ","):
+		return strings.TrimPrefix(spec, "==")
+	}
+	return spec
+}
+
+// poetryDirectReference formats a VCS or URL install as a Poetry inline
+// table, e.g. {git = "https://host/repo.git", rev = "v1"}. Poetry has no
+// editable installs of these, so editable is ignored.
+func poetryDirectReference(url string, editable bool) string {
+	if !strings.HasPrefix(url, "git+") {
+		return "{url = " + strconv.Quote(url) + "}"
+	}
+	repository, ref := splitVCSRef(strings.TrimPrefix(url, "git+"))
+	value := "{git = " + strconv.Quote(repository)
+	if ref != "" {
+		value += ", rev = " + strconv.Quote(ref)
+	}
+	return value + "}"
+}
+
+// readPoetryDependencies returns the [tool.poetry.dependencies] of a
+// pyproject.toml as requirement lines, with Poetry's bare versions read as
+// "==" pins. The python entry is left out.
+func readPoetryDependencies(path string) ([]string, error) {
+	dependencies, err := readTomlDependencies(path, poetryDependenciesTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var requirements []string
+	for _, dependency := range dependencies {
+		if dependency.name == "python" {
+			continue
+		}
+		url := dependency.fields["url"]
+		if git := dependency.fields["git"]; git != "" {
+			url = "git+" + git
+		}
+		if rev := dependency.fields["rev"]; url != "" && rev != "" {
+			url += "@" + rev
+		}
+		spec := dependency.fields["version"]
+		if poetryVersionRegex.MatchString(spec) {
+			spec = "==" + spec
+		}
+		requirements = append(requirements, dependency.requirement(spec, url))
+	}
+	return requirements, nil
+}
@@ -0,0 +1,218 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWritePoetryExtras(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pyproject.toml")
+	existing := `[tool.poetry]
+name = "service"
+
+[tool.poetry.dependencies]
+python = "^3.11"
+requests = "2.28.0"
+
+[build-system]
+requires = ["poetry-core"]
+`
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGenerator(Options{OutputFile: path, Format: FormatPoetry})
+	requirements := []string{
+		"requests[socks]==2.31.0",
+		"uvicorn[standard, watch]>=0.23",
+		`pywin32==306 ; sys_platform == "win32"`,
+		"six",
+	}
+	if err := g.writePoetry(requirements); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[tool.poetry]
+name = "service"
+
+[tool.poetry.dependencies]
+python = "^3.11"
+requests = {version = "2.31.0", extras = ["socks"]}
+uvicorn = {version = ">=0.23", extras = ["standard", "watch"]}
+pywin32 = {version = "306", markers = "sys_platform == \"win32\""}
+six = "*"
+
+[build-system]
+requires = ["poetry-core"]
+`
+	if string(content) != want {
+		t.Errorf("pyproject.toml:\n%s\nwant:\n%s", content, want)
+	}
+
+	dependencies, err := readPoetryDependencies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDependencies := []string{
+		"requests[socks]==2.31.0",
+		"uvicorn[standard,watch]>=0.23",
+		`pywin32==306 ; sys_platform == "win32"`,
+		"six",
+	}
+	if !reflect.DeepEqual(dependencies, wantDependencies) {
+		t.Errorf("readPoetryDependencies = %q, want %q", dependencies, wantDependencies)
+	}
+}
+
+func TestWritePipfileExtras(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Pipfile")
+	g := newTestGenerator(Options{OutputFile: path, Format: FormatPipfile})
+	if err := g.writePipfile([]string{"requests[socks]==2.31.0", "-e git+https://host/repo.git@v1#egg=tool"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dependencies, err := readPipfilePackages(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"requests[socks]==2.31.0", "-e git+https://host/repo.git@v1#egg=tool"}
+	if !reflect.DeepEqual(dependencies, want) {
+		t.Errorf("readPipfilePackages = %q, want %q", dependencies, want)
+	}
+}
+
+func TestKeepExtrasOutput(t *testing.T) {
+	freeze := "requests[socks]==2.31.0\nsix==1.16.0\n"
+	tests := []struct {
+		name       string
+		format     Format
+		keepExtras bool
+		want       string
+	}{
+		{"txt", FormatTxt, true, "requests[socks]==2.31.0\nsix==1.16.0\n"},
+		{"txt without extras", FormatTxt, false, "requests==2.31.0\nsix==1.16.0\n"},
+		{"pyproject", FormatPyproject, true, "[project]\ndependencies = [\n    \"requests[socks]==2.31.0\",\n    \"six==1.16.0\",\n]\n"},
+		{"pyproject without extras", FormatPyproject, false, "[project]\ndependencies = [\n    \"requests==2.31.0\",\n    \"six==1.16.0\",\n]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"app.py": "import requests\nimport six\n"})
+			output := filepath.Join(dir, DefaultOutputFile(tt.format))
+			g, _ := scanWithFreeze(t, Options{TargetDirs: []string{dir}, OutputFile: output, Format: tt.format, KeepExtras: tt.keepExtras}, freeze)
+			if err := g.Write(); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("%s:\n%s\nwant:\n%s", filepath.Base(output), content, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultilineTomlDependencies(t *testing.T) {
+	dir := t.TempDir()
+	pipfile := filepath.Join(dir, "Pipfile")
+	existing := `[[source]]
+url = "https://pypi.org/simple"
+name = "pypi"
+
+[packages]
+requests = {version = "==2.28.0", extras = [
+    "socks",  # proxies [optional]
+]}
+tool = {git = "https://host/tool.git", ref = "v1", editable = true}
+six = "*"
+
+[dev-packages]
+pytest = "*"
+`
+	if err := os.WriteFile(pipfile, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	packages, err := readPipfilePackages(pipfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"requests[socks]==2.28.0", "-e git+https://host/tool.git@v1#egg=tool", "six"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("readPipfilePackages = %q, want %q", packages, want)
+	}
+
+	g := newTestGenerator(Options{OutputFile: pipfile, Format: FormatPipfile})
+	if err := g.writePipfile([]string{"requests==2.31.0"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(pipfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[[source]]
+url = "https://pypi.org/simple"
+name = "pypi"
+
+[packages]
+requests = "==2.31.0"
+
+[dev-packages]
+pytest = "*"
+`
+	if string(content) != want {
+		t.Errorf("Pipfile:\n%s\nwant:\n%s", content, want)
+	}
+
+	// A python entry spanning lines is kept whole
+	pyproject := filepath.Join(dir, "pyproject.toml")
+	existing = `[tool.poetry.dependencies]
+requests = { version = "2.28.0", extras = [
+    "socks",
+] }
+python = [
+    { version = "^3.11", platform = "linux" },
+    { version = "^3.10", platform = "darwin" },
+]
+six = "*"
+
+[build-system]
+requires = ["poetry-core"]
+`
+	if err := os.WriteFile(pyproject, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dependencies, err := readPoetryDependencies(pyproject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"requests[socks]==2.28.0", "six"}; !reflect.DeepEqual(dependencies, want) {
+		t.Errorf("readPoetryDependencies = %q, want %q", dependencies, want)
+	}
+
+	g = newTestGenerator(Options{OutputFile: pyproject, Format: FormatPoetry})
+	if err := g.writePoetry([]string{"requests==2.31.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if content, err = os.ReadFile(pyproject); err != nil {
+		t.Fatal(err)
+	}
+	want = `[tool.poetry.dependencies]
+python = [
+    { version = "^3.11", platform = "linux" },
+    { version = "^3.10", platform = "darwin" },
+]
+requests = "2.31.0"
+
+[build-system]
+requires = ["poetry-core"]
+`
+	if string(content) != want {
+		t.Errorf("pyproject.toml:\n%s\nwant:\n%s", content, want)
+	}
+}
@@ -0,0 +1,264 @@
+package pyreqs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	tomlTableRegex        = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(#.*)?$`)
+	tomlDependenciesRegex = regexp.MustCompile(`^\s*dependencies\s*=`)
+)
+
+// writePyproject replaces the dependencies array of the [project] table in
+// the output file, creating the table or the file when missing. Only the
+// dependencies value is rewritten, so comments and other tables survive.
+//
+// TOML libraries drop comments when encoding, so the file is edited line by
+// line instead, and the result is parsed to make sure the edit kept it valid.
+// The dependencies array must be written with the key on its own line, as
+// in "dependencies = [", not as a dotted key or inline table.
+func (g *Generator) writePyproject(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	updated := setTomlArray(string(content), "project", "dependencies", tomlDependenciesRegex, requirements)
+	if err := checkTomlEdit(g.outputFile, string(content), updated); err != nil {
+		return err
+	}
+	return os.WriteFile(g.outputFile, []byte(updated), 0644)
+}
+
+// checkTomlEdit returns an error when the line-based editing of the valid
+// TOML document before produced an invalid one, so a layout it does not
+// handle never corrupts the file. Documents that were invalid to begin with
+// are not checked.
+func checkTomlEdit(path, before, after string) error {
+	var document map[string]interface{}
+	if _, err := toml.Decode(before, &document); err != nil {
+		return nil
+	}
+	if _, err := toml.Decode(after, &document); err != nil {
+		return fmt.Errorf("cannot update '%s' in place, its layout is not supported: %v", path, err)
+	}
+	return nil
+}
+
+// readPyprojectDependencies returns the [project] dependencies of a
+// pyproject.toml.
+func readPyprojectDependencies(path string) ([]string, error) {
+	var pyproject struct {
+		Project struct {
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"project"`
+	}
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil, err
+	}
+	return pyproject.Project.Dependencies, nil
+}
+
+// setTomlArray sets key (matched by keyRegex) in table to a string array of
+// values and returns the updated document.
+func setTomlArray(content, table, key string, keyRegex *regexp.Regexp, values []string) string {
+	lines := strings.Split(content, "\n")
+	assignment := strings.Split(formatTomlArray(key, values), "\n")
+
+	start, end := tomlTableRange(lines, table)
+	if start < 0 {
+		// Append a new table, separated from existing content by a blank line
+		content = strings.TrimRight(content, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		return content + "[" + table + "]\n" + strings.Join(assignment, "\n") + "\n"
+	}
+
+	topLevel := tomlTopLevelLines(lines)
+	for i := start + 1; i < end; i++ {
+		if !topLevel[i] || !keyRegex.MatchString(lines[i]) {
+			continue
+		}
+
+		// Find the line holding the end of the existing value
+		value := strings.Join(lines[i:end], "\n")
+		equals := strings.Index(value, "=") + 1
+		valueEnd := equals + tomlArrayEnd(value[equals:])
+		last := i + strings.Count(value[:valueEnd], "\n")
+
+		// Keep a trailing comment after the value on the same line
+		rest := value[valueEnd:]
+		if newline := strings.Index(rest, "\n"); newline >= 0 {
+			rest = rest[:newline]
+		}
+		if strings.TrimSpace(rest) != "" {
+			assignment[len(assignment)-1] += rest
+		}
+		return joinLines(lines[:i], assignment, lines[last+1:])
+	}
+
+	// Insert the key after the last non-blank line of the table
+	insert := end
+	for insert > start+1 && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+	return joinLines(lines[:insert], assignment, lines[insert:])
+}
+
+// tomlTableRange returns the index of the header line of table and the index
+// of the line that starts the next table, or -1 when table is absent. Lines
+// inside multi-line arrays, inline tables and strings are not headers.
+func tomlTableRange(lines []string, table string) (start, end int) {
+	return headerRange(lines, table, tomlTopLevelLines(lines))
+}
+
+// iniSectionRange is tomlTableRange for INI files such as setup.cfg, whose
+// section headers share the TOML table syntax but whose values are plain
+// text.
+func iniSectionRange(lines []string, section string) (start, end int) {
+	return headerRange(lines, section, nil)
+}
+
+// headerRange returns the range of the table or section called name, only
+// taking the lines marked in topLevel as headers when it is not nil.
+func headerRange(lines []string, name string, topLevel []bool) (start, end int) {
+	start = -1
+	for i, line := range lines {
+		if topLevel != nil && !topLevel[i] {
+			continue
+		}
+		match := tomlTableRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if start >= 0 {
+			return start, i
+		}
+		if match[1] == name && !strings.HasPrefix(strings.TrimSpace(line), "[[") {
+			start = i
+		}
+	}
+	return start, len(lines)
+}
+
+// tomlTopLevelLines reports for every line whether it starts outside of any
+// multi-line array, inline table or string, so that it can hold a table
+// header or a key.
+func tomlTopLevelLines(lines []string) []bool {
+	topLevel := make([]bool, len(lines))
+	depth := 0
+	multiline := "" // the delimiter of the multi-line string being read
+	for n, line := range lines {
+		topLevel[n] = depth == 0 && multiline == ""
+		for i := 0; i < len(line); i++ {
+			if multiline != "" {
+				if strings.HasPrefix(line[i:], multiline) {
+					i += len(multiline) - 1
+					multiline = ""
+				} else if multiline == `"""` && line[i] == '\\' {
+					i++
+				}
+				continue
+			}
+			switch c := line[i]; c {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			case '"', '\'':
+				if delimiter := strings.Repeat(string(c), 3); strings.HasPrefix(line[i:], delimiter) {
+					multiline = delimiter
+					i += 2
+					continue
+				}
+				for i++; i < len(line) && line[i] != c; i++ {
+					if c == '"' && line[i] == '\\' {
+						i++
+					}
+				}
+			case '#':
+				i = len(line)
+			}
+		}
+	}
+	return topLevel
+}
+
+// tomlArrayEnd returns the offset just past the closing bracket of the array
+// value at the start of value, skipping strings and comments.
+func tomlArrayEnd(value string) int {
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '"', '\'':
+			for i++; i < len(value) && value[i] != c; i++ {
+				if c == '"' && value[i] == '\\' {
+					i++
+				}
+			}
+		case '#':
+			for i < len(value) && value[i] != '\n' {
+				i++
+			}
+		case '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(value)
+}
+
+// parseTomlStringArray extracts the string values of a TOML array literal.
+func parseTomlStringArray(value string) []string {
+	var values []string
+	for _, match := range tomlStringRegex.FindAllString(value, -1) {
+		if strings.HasPrefix(match, "'") {
+			values = append(values, strings.Trim(match, "'"))
+		} else if unquoted, err := strconv.Unquote(match); err == nil {
+			values = append(values, unquoted)
+		}
+	}
+	return values
+}
+
+var tomlStringRegex = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+
+// formatTomlArray renders key as a multi-line TOML array of strings.
+func formatTomlArray(key string, values []string) string {
+	if len(values) == 0 {
+		return key + " = []"
+	}
+
+	var b strings.Builder
+	b.WriteString(key + " = [\n")
+	for _, value := range values {
+		b.WriteString("    " + strconv.Quote(value) + ",\n")
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+func joinLines(parts ...[]string) string {
+	var lines []string
+	for _, part := range parts {
+		lines = append(lines, part...)
+	}
+	return strings.Join(lines, "\n")
+}
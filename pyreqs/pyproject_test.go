@@ -0,0 +1,143 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWritePyprojectLayouts(t *testing.T) {
+	tests := []struct {
+		name, existing, want string
+		dependencies         []string
+	}{
+		{
+			name: "comments with brackets",
+			existing: `[project]
+name = "service"
+dependencies = [ # keep [sorted]
+    "requests",  # see ] here
+    # old: ["six"]
+    "flask[async]>=2",
+]  # trailing ]
+
+[build-system]
+requires = ["setuptools"]
+`,
+			dependencies: []string{"requests", "flask[async]>=2"},
+			want: `[project]
+name = "service"
+dependencies = [
+    "requests==2.31.0",
+]  # trailing ]
+
+[build-system]
+requires = ["setuptools"]
+`,
+		},
+		{
+			name: "header inside a multi-line string",
+			existing: `[project]
+name = "service"
+description = """
+Install with "pip":
+[build-system]
+"""
+
+[build-system]
+requires = ["setuptools"]
+`,
+			want: `[project]
+name = "service"
+description = """
+Install with "pip":
+[build-system]
+"""
+dependencies = [
+    "requests==2.31.0",
+]
+
+[build-system]
+requires = ["setuptools"]
+`,
+		},
+		{
+			name: "header inside a multi-line array",
+			existing: `[project]
+name = "service"
+keywords = [
+    "it's [x",
+    ["nested"]
+]
+authors = [
+    { name = "A", email = "a@example.com" },
+]
+dependencies = ["six"]
+`,
+			dependencies: []string{"six"},
+			want: `[project]
+name = "service"
+keywords = [
+    "it's [x",
+    ["nested"]
+]
+authors = [
+    { name = "A", email = "a@example.com" },
+]
+dependencies = [
+    "requests==2.31.0",
+]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pyproject.toml")
+			if err := os.WriteFile(path, []byte(tt.existing), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dependencies, err := readPyprojectDependencies(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dependencies, tt.dependencies) {
+				t.Errorf("readPyprojectDependencies = %q, want %q", dependencies, tt.dependencies)
+			}
+
+			g := newTestGenerator(Options{OutputFile: path, Format: FormatPyproject})
+			if err := g.writePyproject([]string{"requests==2.31.0"}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("pyproject.toml:\n%s\nwant:\n%s", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePyprojectUnsupportedLayout(t *testing.T) {
+	// A dotted key is not found as [project], so appending the table would
+	// define project twice
+	path := filepath.Join(t.TempDir(), "pyproject.toml")
+	existing := "project.name = \"service\"\nproject.dependencies = [\"six\"]\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGenerator(Options{OutputFile: path, Format: FormatPyproject})
+	if err := g.writePyproject([]string{"requests==2.31.0"}); err == nil {
+		t.Error("writePyproject with a dotted project key succeeded, want an error")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != existing {
+		t.Errorf("pyproject.toml changed to:\n%s", content)
+	}
+}
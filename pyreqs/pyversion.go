@@ -0,0 +1,87 @@
+package pyreqs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pythonVersionSyntax lists syntax that needs a minimum Python 3 minor
+// version. Patterns run on code with comments and docstrings removed.
+var pythonVersionSyntax = []struct {
+	minor   int
+	pattern *regexp.Regexp
+}{
+	{7, regexp.MustCompile(`(?m)^[ \t]*from\s+__future__\s+import\s+[^\n]*\bannotations\b`)},
+	{8, regexp.MustCompile(`:=`)},
+	{10, regexp.MustCompile(`(?m)^[ \t]*match\s+[^\n]+:[ \t]*\n(?:[ \t]*\n)*[ \t]+case\s`)},
+	{11, regexp.MustCompile(`(?m)^[ \t]*except\s*\*`)},
+	{12, regexp.MustCompile(`(?m)^[ \t]*type\s+[A-Za-z_]\w*(?:\[[^\]\n]*\])?\s*=`)},
+}
+
+// pythonVersionModules maps standard-library modules to the Python 3 minor
+// version that added them.
+var pythonVersionModules = map[string]int{
+	"contextvars": 7,
+	"dataclasses": 7,
+	"graphlib":    9,
+	"zoneinfo":    9,
+	"tomllib":     11,
+}
+
+// detectPythonVersion returns the minor version of the oldest Python 3, such
+// as 9 for Python 3.9, able to run content, judging by its syntax and the
+// standard-library modules it imports. It returns 0 when nothing points past
+// Python 3.6.
+func detectPythonVersion(content string, modules []string) int {
+	minor := 0
+	code := stripCommentsAndDocstrings(content)
+	for _, syntax := range pythonVersionSyntax {
+		if syntax.minor > minor && syntax.pattern.MatchString(code) {
+			minor = syntax.minor
+		}
+	}
+	for _, module := range modules {
+		if required := pythonVersionModules[module]; required > minor {
+			minor = required
+		}
+	}
+	return minor
+}
+
+// pythonVersionRegex matches a "3.11" version, or the "Python 3.11.4" line
+// printed by "python --version".
+var pythonVersionRegex = regexp.MustCompile(`^(?:Python\s+)?3\.(\d+)(?:\.\S*)?$`)
+
+// ParsePythonVersion converts a flag value such as "3.11" into its Python 3
+// minor version.
+func ParsePythonVersion(value string) (int, error) {
+	match := pythonVersionRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid Python version '%s' (want 3.<minor>, e.g. 3.11)", value)
+	}
+	return strconv.Atoi(match[1])
+}
+
+// interpreterPythonMinor asks the interpreter for its version and returns its
+// Python 3 minor version.
+func (g *Generator) interpreterPythonMinor() (int, error) {
+	output, err := g.runCommand("python --version", func(ctx context.Context) (*exec.Cmd, error) {
+		return g.pythonCommand(ctx, "--version")
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ParsePythonVersion(string(output))
+}
+
+// formatPythonVersion returns "3.<minor>", or "" for 0.
+func formatPythonVersion(minor int) string {
+	if minor == 0 {
+		return ""
+	}
+	return fmt.Sprintf("3.%d", minor)
+}
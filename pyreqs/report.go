@@ -0,0 +1,143 @@
+package pyreqs
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Report is the machine-readable result of a Scan, written by FormatJSON.
+type Report struct {
+	Requirements []Requirement `json:"requirements"`
+	Unresolved   []string      `json:"unresolved"`
+}
+
+// Requirement describes one matched package.
+type Requirement struct {
+	Name string `json:"name"`
+	// Version is the version Line pins or starts from, empty when it names
+	// none.
+	Version string `json:"version"`
+	Line    string `json:"line"`
+	// Imports lists the import statements that need the package.
+	Imports []ImportSite `json:"imports,omitempty"`
+}
+
+// ImportSite is where a scanned file imports a module. Line is 0 for modules
+// that are not imported by a statement, such as entry points.
+type ImportSite struct {
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// Report returns the result of the last Scan as a Report.
+func (g *Generator) Report() Report {
+	report := Report{
+		Requirements: []Requirement{},
+		Unresolved:   append([]string{}, g.unresolved...),
+	}
+
+	for _, line := range g.requirements {
+		report.Requirements = append(report.Requirements, Requirement{
+			Name:    requirementDistribution(line),
+			Version: requirementVersion(line),
+			Line:    line,
+			Imports: g.requirementImports(line),
+		})
+	}
+
+	return report
+}
+
+// requirementVersion returns the version a requirement line pins or starts
+// from, e.g. "1.26.0" for "numpy==1.26.0", "numpy~=1.26.0" and
+// "numpy>=1.26.0,<2.0", so it always agrees with the line written after
+// overrides, pin styles and StripLocalVersion. It is empty for lines without
+// such a version, such as bare names and direct references.
+func requirementVersion(line string) string {
+	if _, _, _, ok := directReference(line); ok {
+		return ""
+	}
+	line, _ = splitMarker(line)
+	line = stripExtras(line)
+	spec := strings.TrimSpace(line[len(requirementDistribution(line)):])
+	spec = strings.TrimSpace(strings.SplitN(spec, ",", 2)[0])
+	for _, operator := range []string{"===", "==", "~=", ">=", "^"} {
+		if strings.HasPrefix(spec, operator) {
+			return strings.TrimSpace(spec[len(operator):])
+		}
+	}
+	return ""
+}
+
+// requirementImports returns the import sites of the modules of the
+// requirement's package, by module then file.
+func (g *Generator) requirementImports(line string) []ImportSite {
+	var sites []ImportSite
+	for _, module := range g.packageModules[requirementName(line)] {
+		sites = append(sites, g.importSites[module]...)
+	}
+	return sites
+}
+
+func (g *Generator) writeJSON() error {
+	file, err := createOutputFile(g.outputFile)
+	if err != nil {
+		return err
+	}
+	if err := g.WriteReport(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteReport writes the Report of the last Scan to w as indented JSON.
+func (g *Generator) WriteReport(w io.Writer) error {
+	// Keep specifiers such as ">=" readable instead of escaping them
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g.Report())
+}
+
+// readJSONRequirements returns the requirement lines of a JSON report.
+func readJSONRequirements(path string) ([]string, error) {
+	data, err := readOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, req := range report.Requirements {
+		lines = append(lines, req.Line)
+	}
+	return lines, nil
+}
+
+// splitMarker splits a requirement line into the requirement and its
+// environment marker, e.g. `pywin32==306 ; sys_platform == "win32"`.
+func splitMarker(line string) (requirement, marker string) {
+	if i := strings.Index(line, ";"); i >= 0 {
+		return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	}
+	return line, ""
+}
+
+// requirementDistribution returns the distribution name of a requirement
+// line as written, e.g. "Flask" for "Flask==2.3.2".
+func requirementDistribution(line string) string {
+	if name, _, _, ok := directReference(line); ok {
+		return name
+	}
+	if end := strings.IndexAny(line, "=<>!~^;[@ "); end >= 0 {
+		return line[:end]
+	}
+	return line
+}
@@ -0,0 +1,93 @@
+package pyreqs
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRequirementVersion(t *testing.T) {
+	tests := map[string]string{
+		"numpy==1.26.0":                          "1.26.0",
+		"numpy===1.26.0":                         "1.26.0",
+		"requests~=2.31.0":                       "2.31.0",
+		"requests>=2.31,<3.0":                    "2.31",
+		"requests^2.31.0":                        "2.31.0",
+		"requests[socks]==2.31.0":                "2.31.0",
+		`pywin32==306 ; sys_platform == "win32"`: "306",
+		"requests":                               "",
+		"requests<3":                             "",
+		"tool @ git+https://host/tool.git@v1.0":  "",
+	}
+	for line, want := range tests {
+		if got := requirementVersion(line); got != want {
+			t.Errorf("requirementVersion(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestReportVersionMatchesLine(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.py": "import numpy\nimport torch\nimport requests\n"})
+	opts := Options{
+		TargetDirs:        []string{dir},
+		VersionOverrides:  map[string]string{"numpy": "1.26.0"},
+		StripLocalVersion: true,
+		PinStyle:          PinMinimum,
+	}
+	g, _ := scanWithFreeze(t, opts, "numpy==1.25.2\ntorch==2.1.0+cu118\nrequests==2.31.0\n")
+
+	var got []Requirement
+	for _, req := range g.Report().Requirements {
+		got = append(got, Requirement{Name: req.Name, Version: req.Version, Line: req.Line})
+	}
+	want := []Requirement{
+		{Name: "numpy", Version: "1.26.0", Line: "numpy>=1.26.0"},
+		{Name: "requests", Version: "2.31.0", Line: "requests>=2.31.0"},
+		{Name: "torch", Version: "2.1.0", Line: "torch>=2.1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report().Requirements = %+v, want %+v", got, want)
+	}
+
+	var csv bytes.Buffer
+	if err := g.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(dir, "app.py")
+	wantCSV := "package,version,source_files\nnumpy,1.26.0," + app + "\nrequests,2.31.0," + app + "\ntorch,2.1.0," + app + "\n"
+	if csv.String() != wantCSV {
+		t.Errorf("WriteCSV:\n%s\nwant:\n%s", csv.String(), wantCSV)
+	}
+}
+
+func TestWriteReportVersionMatchesLine(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.py": "import numpy\nimport torch\n"})
+	opts := Options{
+		TargetDirs:        []string{dir},
+		Format:            FormatJSON,
+		VersionOverrides:  map[string]string{"numpy": "1.26.0"},
+		StripLocalVersion: true,
+	}
+	g, _ := scanWithFreeze(t, opts, "numpy==1.25.2\ntorch==2.1.0+cu118\n")
+
+	var b bytes.Buffer
+	if err := g.WriteReport(&b); err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatalf("report %s: %v", b.String(), err)
+	}
+	var got [][2]string
+	for _, req := range report.Requirements {
+		got = append(got, [2]string{req.Version, req.Line})
+	}
+	want := [][2]string{{"1.26.0", "numpy==1.26.0"}, {"2.1.0", "torch==2.1.0"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("report versions and lines = %q, want %q", got, want)
+	}
+}
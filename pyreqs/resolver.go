@@ -0,0 +1,80 @@
+package pyreqs
+
+// Resolver maps an imported module to the installed package providing it.
+// Generator tries the Resolvers of its Options in order before its built-in
+// matching, so custom resolvers can encode conventions such as an internal
+// "acme_<name>" import for every "acme-<name>" distribution:
+//
+//	opts.Resolvers = []pyreqs.Resolver{pyreqs.ResolverFunc(func(importName string, installed map[string]string) (string, bool) {
+//		name, ok := strings.CutPrefix(importName, "acme_")
+//		if !ok {
+//			return "", false
+//		}
+//		line, ok := installed["acme-"+name]
+//		return line, ok
+//	})}
+//
+// StaticResolver is the only built-in Resolver exported. The import names
+// read from importlib.metadata with SourceMetadata are not available as a
+// Resolver: the Generator applies them itself, after the custom resolvers
+// and ImportMappings.
+type Resolver interface {
+	// Resolve returns the "pip freeze" line of the package providing
+	// importName, a module name as matched (see Options.NamespaceDepths),
+	// usually taken from installed, which maps lowercased package names to
+	// their lines. It returns false to leave the module to the next
+	// resolver. A line naming a package that is not installed reports the
+	// module as unresolved.
+	Resolve(importName string, installed map[string]string) (line string, ok bool)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(importName string, installed map[string]string) (string, bool)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(importName string, installed map[string]string) (string, bool) {
+	return f(importName, installed)
+}
+
+// StaticResolver resolves modules through a table of import name to
+// distribution name, such as Options.ImportMappings. Names are compared
+// after PEP 503 normalization. Modules whose distribution is not installed
+// are left to the next resolver.
+type StaticResolver map[string]string
+
+// Resolve implements Resolver.
+func (r StaticResolver) Resolve(importName string, installed map[string]string) (string, bool) {
+	for name, pkgName := range r {
+		if pep503Normalize(name) == pep503Normalize(importName) {
+			return installedLine(pkgName, installed)
+		}
+	}
+	return "", false
+}
+
+// resolveWith returns the line of the first of resolvers to resolve module.
+func resolveWith(resolvers []Resolver, module string, installed map[string]string) (string, bool) {
+	for _, resolver := range resolvers {
+		if line, ok := resolver.Resolve(module, installed); ok {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// installedLine returns the line of the package named pkgName in installed.
+func installedLine(pkgName string, installed map[string]string) (string, bool) {
+	for name, line := range installed {
+		if pep503Normalize(name) == pep503Normalize(pkgName) {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// builtinResolvers returns the import name tables tried after the custom
+// resolvers, from the most to the least specific: ImportMappings, the
+// importlib.metadata names of SourceMetadata and the bundled table.
+func (g *Generator) builtinResolvers() []Resolver {
+	return []Resolver{StaticResolver(g.importMappings), StaticResolver(g.metadataMappings), StaticResolver(importToPackage)}
+}
@@ -0,0 +1,66 @@
+package pyreqs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolverChainOrder(t *testing.T) {
+	var called []string
+	acme := ResolverFunc(func(importName string, installed map[string]string) (string, bool) {
+		called = append(called, importName)
+		if name, ok := strings.CutPrefix(importName, "acme_"); ok {
+			return installedLine("acme-"+name, installed)
+		}
+		// Claims every other module, so it only sees what the static table passes on
+		return installed["opencv-python"], true
+	})
+	opts := Options{
+		Resolvers: []Resolver{
+			StaticResolver{"cv2": "opencv-contrib-python", "yaml": "not-installed"},
+			acme,
+		},
+		ImportMappings: map[string]string{"cv2": "opencv-python-headless"},
+	}
+	freeze := "acme-tools==1.0\nopencv-contrib-python==4.8.0.76\nopencv-python==4.8.0.74\nopencv-python-headless==4.8.0.74\n"
+
+	got := requirementsFor(t, opts, freeze, "import cv2\nimport acme_tools\n")
+	if want := []string{"acme-tools==1.0", "opencv-contrib-python==4.8.0.76"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+	// cv2 was settled by the StaticResolver before the function saw it
+	if want := []string{"acme_tools"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("ResolverFunc called for %v, want %v", called, want)
+	}
+
+	// A static entry whose distribution is not installed passes to the next
+	called = nil
+	got = requirementsFor(t, opts, freeze, "import yaml\n")
+	if want := []string{"opencv-python==4.8.0.74"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requirements for yaml = %v, want %v", got, want)
+	}
+	if want := []string{"yaml"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("ResolverFunc called for %v, want %v", called, want)
+	}
+}
+
+func TestStaticResolver(t *testing.T) {
+	installed := map[string]string{"pyyaml": "PyYAML==6.0", "opencv-python": "opencv-python==4.8.0.74"}
+	resolver := StaticResolver{"yaml": "PyYAML", "Open_CV": "opencv_python", "bs4": "beautifulsoup4"}
+	tests := []struct {
+		module string
+		line   string
+		ok     bool
+	}{
+		{"yaml", "PyYAML==6.0", true},
+		{"open-cv", "opencv-python==4.8.0.74", true},
+		{"bs4", "", false},
+		{"requests", "", false},
+	}
+	for _, tt := range tests {
+		if line, ok := resolver.Resolve(tt.module, installed); line != tt.line || ok != tt.ok {
+			t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.module, line, ok, tt.line, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package pyreqs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scan progress is reported every progressFiles files or progressPeriod,
+// whichever comes first.
+const (
+	progressFiles  = 500
+	progressPeriod = 2 * time.Second
+)
+
+// scanResult holds the modules imported by one scanned file.
+type scanResult struct {
+	path    string
+	imports []Import
+	// pythonMinor is the minimum Python 3 minor version the file needs, or 0.
+	pythonMinor int
+	err         error
+}
+
+// findAndProcessPythonFiles walks the target directories to collect the files
+// to scan, then extracts their imports with a pool of workers. Targets that
+// are zip archives are read in place.
+func (g *Generator) findAndProcessPythonFiles() error {
+	var paths, walked []string
+	results := make(map[string]scanResult)
+	modules := make(map[string]bool)
+	for _, dir := range g.targetDirs {
+		if isZipArchive(dir) {
+			archived, err := g.scanZip(dir)
+			if err != nil {
+				return err
+			}
+			for _, result := range archived {
+				paths = append(paths, result.path)
+				results[result.path] = result
+				for _, imp := range result.imports {
+					modules[imp.Name] = true
+				}
+			}
+			continue
+		}
+
+		found, err := g.collectFiles(dir)
+		if err != nil {
+			return err
+		}
+		if g.since != "" {
+			if found, err = g.filterSince(dir, found); err != nil {
+				return fmt.Errorf("failed to list files changed since '%s': %v", g.since, err)
+			}
+		}
+		paths = append(paths, found...)
+		walked = append(walked, found...)
+	}
+
+	// Record results in walk order so discovery order does not depend on
+	// worker scheduling
+	lastProgress := time.Now()
+	for result := range g.processFiles(walked) {
+		results[result.path] = result
+		for _, imp := range result.imports {
+			modules[imp.Name] = true
+		}
+		if g.progress != nil && (len(results)%progressFiles == 0 || time.Since(lastProgress) >= progressPeriod) {
+			g.progress(len(results), len(modules))
+			lastProgress = time.Now()
+		}
+	}
+	if g.progress != nil {
+		g.progress(len(results), len(modules))
+	}
+
+	for _, path := range paths {
+		result := results[path]
+		if result.err != nil {
+			g.warn("could not parse file", "file", result.path, "error", result.err)
+			continue
+		}
+		g.logger.Debug("found imports", "file", result.path, "modules", importNames(result.imports))
+		g.addModules(result.path, result.imports)
+		g.stats.FilesScanned++
+		g.requirePython(result.path, result.pythonMinor)
+	}
+
+	// Keep provenance deterministic regardless of worker scheduling
+	for _, files := range g.moduleSources {
+		sort.Strings(files)
+	}
+	return nil
+}
+
+// collectFiles returns the files under targetDir that should be scanned.
+func (g *Generator) collectFiles(targetDir string) ([]string, error) {
+	var ignore *gitignore
+	if g.useGitignore {
+		ignore = newGitignore(targetDir)
+	}
+
+	// Real paths of the walked directories, so symlink cycles end
+	visited := make(map[string]bool)
+	if real, err := realPath(targetDir); err == nil {
+		visited[real] = true
+	}
+
+	var paths []string
+	var walk filepath.WalkFunc
+	walk = func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Links to files are scanned like the files themselves
+		if g.followSymlinks && info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(path); err == nil && target.IsDir() {
+				return walkSymlinkedDir(path, visited, walk)
+			}
+		}
+
+		if ignore != nil && path != targetDir && ignore.match(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip excluded directories by base name, but never the target itself;
+		// the path's name is used since a followed symlink's info is its target's
+		if info.IsDir() {
+			if path != targetDir && g.excludedDirs[filepath.Base(path)] {
+				return filepath.SkipDir
+			}
+			if abs, err := filepath.Abs(path); err == nil && path != targetDir && g.excludedPaths[abs] {
+				return filepath.SkipDir
+			}
+			if g.maxDepth >= 0 && dirDepth(targetDir, path) > g.maxDepth {
+				// A package beyond the depth limit is still part of the project
+				initFile := filepath.Join(path, "__init__.py")
+				if _, err := os.Stat(initFile); err == nil {
+					g.recordLocalModule(initFile)
+				}
+				return filepath.SkipDir
+			}
+			// Rules of a directory's .gitignore apply to everything below it
+			if ignore != nil {
+				ignore.load(path)
+			}
+			return nil
+		}
+
+		if matchAny(g.excludePatterns, info.Name()) {
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(path, ".py"):
+			g.recordLocalModule(path)
+		case g.includeNotebooks && strings.HasSuffix(path, ".ipynb"):
+		case matchAny(g.includePatterns, info.Name()):
+		case path == targetDir:
+			// A file given as the target is scanned whatever its name, such
+			// as an extensionless script like "manage"
+		default:
+			return nil
+		}
+		if isTestFile(targetDir, path) {
+			g.testFiles[path] = true
+		}
+		paths = append(paths, path)
+		return nil
+	}
+	err := filepath.Walk(targetDir, walk)
+	return paths, err
+}
+
+// walkSymlinkedDir walks the directory the symlink at path points to,
+// reporting its entries to fn under path. Directories already walked are
+// skipped, so symlink cycles end.
+func walkSymlinkedDir(path string, visited map[string]bool, fn filepath.WalkFunc) error {
+	real, err := realPath(path)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(real); err != nil || !info.IsDir() || visited[real] {
+		return nil
+	}
+	visited[real] = true
+
+	return filepath.Walk(real, func(walked string, info os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(real, walked)
+		if relErr != nil {
+			return relErr
+		}
+		return fn(filepath.Join(path, rel), info, err)
+	})
+}
+
+// dirDepth returns how many levels dir lies below targetDir.
+func dirDepth(targetDir, dir string) int {
+	rel, err := filepath.Rel(targetDir, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// matchAny reports whether name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath returns the absolute path of path with all symlinks resolved.
+func realPath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// isTestFile reports whether path, found below targetDir, is part of the test
+// suite: a test_*.py or *_test.py file, or any file in a tests directory.
+func isTestFile(targetDir, path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py") || strings.HasSuffix(name, "_test.py") {
+		return true
+	}
+
+	rel, err := filepath.Rel(targetDir, path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if dir == "tests" {
+			return true
+		}
+	}
+	return false
+}
+
+// addModules records the modules imported by the file at path and where.
+// It is safe for concurrent use.
+func (g *Generator) addModules(path string, imports []Import) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, imp := range imports {
+		module := imp.Name
+		g.importSites[module] = append(g.importSites[module], ImportSite{Module: module, File: path, Line: imp.Line})
+		if !g.foundModules[module] {
+			g.moduleOrder[module] = len(g.moduleOrder)
+		}
+		g.foundModules[module] = true
+		// A file may import the same module more than once
+		if sources := g.moduleSources[module]; len(sources) == 0 || sources[len(sources)-1] != path {
+			g.moduleSources[module] = append(sources, path)
+		}
+	}
+}
+
+// processFiles fans paths out to g.jobs workers and returns a channel that
+// yields one result per path and is closed once every file is scanned.
+func (g *Generator) processFiles(paths []string) <-chan scanResult {
+	pending := make(chan string)
+	results := make(chan scanResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < g.jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range pending {
+				results <- g.scanFile(path)
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range paths {
+			pending <- path
+		}
+		close(pending)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func (g *Generator) scanFile(path string) scanResult {
+	if !strings.HasSuffix(path, ".ipynb") {
+		if info, err := os.Stat(path); err == nil && info.Size() > largeFileSize {
+			return g.scanLargeFile(path)
+		}
+	}
+
+	var content string
+	var err error
+	if strings.HasSuffix(path, ".ipynb") {
+		content, err = readNotebookSource(path)
+	} else {
+		content, err = readPythonSource(path)
+	}
+	if err != nil {
+		return scanResult{path: path, err: err}
+	}
+
+	// Parse Python imports using regex (since we're in Go, we can't use Python's ast)
+	imports := g.extractImports(path, content)
+	return scanResult{path: path, imports: imports, pythonMinor: detectPythonVersion(content, importNames(imports))}
+}
+
+// requirePython raises the minimum Python version of the project to minor,
+// the version needed by the file at path.
+func (g *Generator) requirePython(path string, minor int) {
+	if minor == 0 {
+		return
+	}
+	g.logger.Debug("requires Python", "file", path, "version", ">="+formatPythonVersion(minor))
+	if minor > g.pythonMinor {
+		g.pythonMinor = minor
+	}
+}
+
+// recordLocalModule remembers the module name a project file can be imported
+// as: the file's base name, or its directory's name for package __init__ files.
+func (g *Generator) recordLocalModule(path string) {
+	name := filepath.Base(path)
+	if name == "__init__.py" {
+		g.localModules[filepath.Base(filepath.Dir(path))] = true
+		return
+	}
+	g.localModules[strings.TrimSuffix(name, ".py")] = true
+}
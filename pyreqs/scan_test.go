@@ -0,0 +1,293 @@
+package pyreqs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// writeFiles creates the files of contents, keyed by slash-separated path
+// relative to dir, with their parent directories.
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	t.Helper()
+	for name, content := range contents {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// symlink creates a symlink at link pointing to target, skipping the test
+// where symlinks cannot be created.
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+}
+
+// relPaths returns paths relative to dir with forward slashes, sorted.
+func relPaths(t *testing.T, dir string, paths []string) []string {
+	t.Helper()
+	var rels []string
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+func TestCollectFilesFollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"shared/common/util.py": "import requests\n",
+		"shared/lone.py":        "import six\n",
+		"project/app/main.py":   "import flask\n",
+	})
+	project := filepath.Join(root, "project")
+	// A symlinked subdirectory, a symlinked file and a cycle back to the root
+	symlink(t, filepath.Join(root, "shared", "common"), filepath.Join(project, "common"))
+	symlink(t, filepath.Join(root, "shared", "lone.py"), filepath.Join(project, "app", "linked.py"))
+	symlink(t, project, filepath.Join(project, "app", "loop"))
+
+	tests := []struct {
+		follow bool
+		want   []string
+	}{
+		{false, []string{"app/linked.py", "app/main.py"}},
+		{true, []string{"app/linked.py", "app/main.py", "common/util.py"}},
+	}
+	for _, tt := range tests {
+		g := newTestGenerator(Options{TargetDirs: []string{project}, FollowSymlinks: tt.follow})
+		paths, err := g.collectFiles(project)
+		if err != nil {
+			t.Fatalf("collectFiles(follow=%v): %v", tt.follow, err)
+		}
+		if got := relPaths(t, project, paths); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("collectFiles(follow=%v) = %v, want %v", tt.follow, got, tt.want)
+		}
+	}
+}
+
+// scanWithFreeze scans the targets of opts against the packages listed in
+// freeze, "pip freeze" output, without running pip or Python, and returns
+// the Generator and the requirements.
+func scanWithFreeze(t *testing.T, opts Options, freeze string) (*Generator, []string) {
+	t.Helper()
+	opts.FreezeFile = filepath.Join(t.TempDir(), "freeze.txt")
+	if err := os.WriteFile(opts.FreezeFile, []byte(freeze), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts.NoVirtualenv = true
+	if opts.TargetPythonMinor == 0 {
+		opts.TargetPythonMinor = 11
+	}
+	g := newTestGenerator(opts)
+	requirements, err := g.Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return g, requirements
+}
+
+func TestAddModulesConcurrent(t *testing.T) {
+	g := newTestGenerator(Options{})
+	modules := []string{"requests", "flask", "numpy", "six"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("file%d.py", i)
+			for line, module := range modules {
+				g.addModules(path, []Import{{Name: module, Line: line + 1}})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := sortedKeys(g.foundModules); !reflect.DeepEqual(got, []string{"flask", "numpy", "requests", "six"}) {
+		t.Errorf("found modules = %v", got)
+	}
+	for _, module := range modules {
+		if n := len(g.moduleSources[module]); n != 16 {
+			t.Errorf("%s has %d sources, want 16", module, n)
+		}
+	}
+}
+
+func TestScanParallel(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	modules := []string{"requests", "flask", "numpy", "six"}
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("pkg%d/mod%d.py", i%10, i)] = "import " + modules[i%len(modules)] + "\n"
+	}
+	writeFiles(t, dir, files)
+	freeze := "Flask==2.3.2\nnumpy==1.24.3\nrequests==2.31.0\nsix==1.16.0\n"
+
+	want := []string{"Flask==2.3.2", "numpy==1.24.3", "requests==2.31.0", "six==1.16.0"}
+	for _, jobs := range []int{1, 8} {
+		g, requirements := scanWithFreeze(t, Options{TargetDir: dir, Jobs: jobs}, freeze)
+		if !reflect.DeepEqual(requirements, want) {
+			t.Errorf("requirements with %d jobs = %v, want %v", jobs, requirements, want)
+		}
+		if n := g.Stats().FilesScanned; n != 200 {
+			t.Errorf("scanned %d files with %d jobs, want 200", n, jobs)
+		}
+	}
+}
+
+func TestScanMultipleTargets(t *testing.T) {
+	src, scripts := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"app.py": "import requests\n"})
+	writeFiles(t, scripts, map[string]string{"tool.py": "import flask\nimport requests\n"})
+
+	g, requirements := scanWithFreeze(t, Options{TargetDirs: []string{src, scripts}}, "Flask==2.3.2\nrequests==2.31.0\nsix==1.16.0\n")
+	want := []string{"Flask==2.3.2", "requests==2.31.0"}
+	if !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+	if n := g.Stats().FilesScanned; n != 2 {
+		t.Errorf("scanned %d files, want 2", n)
+	}
+}
+
+func TestScanDevRequirements(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app/main.py":          "import requests\nimport six\n",
+		"tests/test_client.py": "import pytest\nimport requests\n",
+		"tests/conftest.py":    "import responses\n",
+		"app/test_util.py":     "import hypothesis\n",
+		"app/util_test.py":     "import six\nimport freezegun\n",
+	})
+	freeze := "freezegun==1.2.2\nhypothesis==6.82.0\npytest==7.4.0\nrequests==2.31.0\nresponses==0.23.3\nsix==1.16.0\n"
+	opts := Options{TargetDirs: []string{dir}, DevOutputFile: filepath.Join(dir, "requirements-dev.txt")}
+
+	g, requirements := scanWithFreeze(t, opts, freeze)
+	// Packages imported by both test and runtime files are runtime ones
+	if want := []string{"requests==2.31.0", "six==1.16.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+	wantDev := []string{"freezegun==1.2.2", "hypothesis==6.82.0", "pytest==7.4.0", "responses==0.23.3"}
+	if got := g.DevRequirements(); !reflect.DeepEqual(got, wantDev) {
+		t.Errorf("DevRequirements() = %v, want %v", got, wantDev)
+	}
+
+	// Without a dev output file, test-only packages stay in the requirements
+	_, requirements = scanWithFreeze(t, Options{TargetDirs: []string{dir}}, freeze)
+	if want := []string{"freezegun==1.2.2", "hypothesis==6.82.0", "pytest==7.4.0", "requests==2.31.0", "responses==0.23.3", "six==1.16.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements without a dev output = %v, want %v", requirements, want)
+	}
+}
+
+func TestScanMissingTarget(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	g := newTestGenerator(Options{TargetDirs: []string{t.TempDir(), missing}, NoVirtualenv: true})
+	_, err := g.Scan()
+	var notFound *DirectoryNotFoundError
+	if !errors.As(err, &notFound) || notFound.Dir != missing {
+		t.Errorf("Scan() error = %v, want DirectoryNotFoundError for %s", err, missing)
+	}
+}
+
+func TestCollectFilesDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.py":            "import requests\n",
+		"app/views.py":       "import flask\n",
+		"app/api/routes.py":  "import six\n",
+		"app/api/v1/deep.py": "import numpy\n",
+	})
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"no limit", Options{}, []string{"app/api/routes.py", "app/api/v1/deep.py", "app/views.py", "main.py"}},
+		{"no recursion", Options{NoRecursive: true}, []string{"main.py"}},
+		{"depth 1", Options{MaxDepth: 1}, []string{"app/views.py", "main.py"}},
+		{"depth 2", Options{MaxDepth: 2}, []string{"app/api/routes.py", "app/views.py", "main.py"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.TargetDir = dir
+			paths, err := newTestGenerator(tt.opts).collectFiles(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := relPaths(t, dir, paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectFiles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModulesOnlyRunsNoCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pip")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.py":    "import os\nimport requests\nimport helper\nfrom google.cloud import storage\nimport notinstalled\n",
+		"helper.py": "import yaml\n",
+	})
+	// Every command run leaves a marker behind
+	marker := filepath.Join(dir, "invoked")
+	command := filepath.Join(t.TempDir(), "pip")
+	if err := os.WriteFile(command, []byte("#!/bin/sh\ntouch "+marker+"\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGenerator(Options{TargetDir: dir, ModulesOnly: true, Pip: command, Python: command, NoCache: true})
+	modules, err := g.Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if want := []string{"google.cloud.storage", "notinstalled", "requests", "yaml"}; !reflect.DeepEqual(modules, want) {
+		t.Errorf("modules = %v, want %v", modules, want)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("pip or Python was run with ModulesOnly")
+	}
+}
+
+func TestScanSingleFileTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"manage":   "#!/usr/bin/env python\nimport os\nimport requests\n",
+		"other.py": "import six\n",
+	})
+	freeze := "requests==2.31.0\nsix==1.16.0\n"
+
+	g, requirements := scanWithFreeze(t, Options{TargetDir: filepath.Join(dir, "manage")}, freeze)
+	if want := []string{"requests==2.31.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+	if n := g.Stats().FilesScanned; n != 1 {
+		t.Errorf("scanned %d files, want 1", n)
+	}
+
+	// Extensionless files are still skipped inside a target directory
+	_, requirements = scanWithFreeze(t, Options{TargetDir: dir}, freeze)
+	if want := []string{"six==1.16.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements of the directory = %v, want %v", requirements, want)
+	}
+}
@@ -0,0 +1,170 @@
+package pyreqs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	setupInstallRequiresRegex = regexp.MustCompile(`install_requires\s*=\s*`)
+	setupCallRegex            = regexp.MustCompile(`\bsetup\s*\(`)
+	setupCfgRequiresRegex     = regexp.MustCompile(`^\s*install_requires\s*[=:]`)
+)
+
+// writeSetupPy rewrites the install_requires list literal of the setup()
+// call in setup.py, adding the keyword when it is missing. Values that are
+// not list literals, such as variables, are left alone and reported.
+func (g *Generator) writeSetupPy(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if os.IsNotExist(err) {
+		content = []byte("from setuptools import setup\n\nsetup(\n)\n")
+	} else if err != nil {
+		return err
+	}
+	text := string(content)
+
+	if loc := setupInstallRequiresRegex.FindStringIndex(text); loc != nil {
+		if !strings.HasPrefix(text[loc[1]:], "[") {
+			return fmt.Errorf("install_requires in '%s' is not a list literal", g.outputFile)
+		}
+		indent := lineIndent(text, loc[0])
+		end := loc[1] + tomlArrayEnd(text[loc[1]:])
+		text = text[:loc[0]] + "install_requires=" + formatPythonList(requirements, indent) + text[end:]
+		return os.WriteFile(g.outputFile, []byte(text), 0644)
+	}
+
+	loc := setupCallRegex.FindStringIndex(text)
+	if loc == nil {
+		return fmt.Errorf("no setup() call found in '%s'", g.outputFile)
+	}
+	indent := lineIndent(text, loc[0]) + "    "
+	insert := "\n" + indent + "install_requires=" + formatPythonList(requirements, indent) + ","
+	text = text[:loc[1]] + insert + text[loc[1]:]
+	return os.WriteFile(g.outputFile, []byte(text), 0644)
+}
+
+// writeSetupCfg replaces install_requires in the [options] section of
+// setup.cfg, creating the section or the file when missing.
+func (g *Generator) writeSetupCfg(requirements []string) error {
+	content, err := os.ReadFile(g.outputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	assignment := []string{"install_requires ="}
+	for _, req := range requirements {
+		assignment = append(assignment, "    "+req)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	start, end := iniSectionRange(lines, "options")
+	if start < 0 {
+		text := strings.TrimRight(string(content), "\n")
+		if text != "" {
+			text += "\n\n"
+		}
+		text += "[options]\n" + strings.Join(assignment, "\n") + "\n"
+		return os.WriteFile(g.outputFile, []byte(text), 0644)
+	}
+
+	for i := start + 1; i < end; i++ {
+		if !setupCfgRequiresRegex.MatchString(lines[i]) {
+			continue
+		}
+		last := i + 1
+		for last < end && isIniContinuation(lines[last]) {
+			last++
+		}
+		text := joinLines(lines[:i], assignment, lines[last:])
+		return os.WriteFile(g.outputFile, []byte(text), 0644)
+	}
+
+	insert := end
+	for insert > start+1 && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+	text := joinLines(lines[:insert], assignment, lines[insert:])
+	return os.WriteFile(g.outputFile, []byte(text), 0644)
+}
+
+// readSetupPyRequires returns the install_requires entries of a setup.py.
+func readSetupPyRequires(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := string(content)
+
+	loc := setupInstallRequiresRegex.FindStringIndex(text)
+	if loc == nil || !strings.HasPrefix(text[loc[1]:], "[") {
+		return nil, nil
+	}
+	return parseTomlStringArray(text[loc[1] : loc[1]+tomlArrayEnd(text[loc[1]:])]), nil
+}
+
+// readSetupCfgRequires returns the [options] install_requires entries of a setup.cfg.
+func readSetupCfgRequires(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	start, end := iniSectionRange(lines, "options")
+	if start < 0 {
+		return nil, nil
+	}
+
+	var requires []string
+	for i := start + 1; i < end; i++ {
+		if !setupCfgRequiresRegex.MatchString(lines[i]) {
+			continue
+		}
+		// The first entry may share the line with the key
+		first := strings.TrimSpace(lines[i][strings.IndexAny(lines[i], "=:")+1:])
+		if first != "" {
+			requires = append(requires, first)
+		}
+		for i++; i < end && isIniContinuation(lines[i]); i++ {
+			if entry := strings.TrimSpace(lines[i]); entry != "" && !strings.HasPrefix(entry, "#") {
+				requires = append(requires, entry)
+			}
+		}
+		break
+	}
+	return requires, nil
+}
+
+// isIniContinuation reports whether line continues the previous INI value.
+func isIniContinuation(line string) bool {
+	return strings.TrimSpace(line) != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"))
+}
+
+// lineIndent returns the leading whitespace of the line containing offset.
+func lineIndent(text string, offset int) string {
+	start := strings.LastIndex(text[:offset], "\n") + 1
+	end := start
+	for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+		end++
+	}
+	return text[start:end]
+}
+
+// formatPythonList renders values as a multi-line Python list whose closing
+// bracket is aligned with indent.
+func formatPythonList(values []string, indent string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+
+	var b strings.Builder
+	b.WriteString("[\n")
+	for _, value := range values {
+		b.WriteString(indent + "    " + strconv.Quote(value) + ",\n")
+	}
+	b.WriteString(indent + "]")
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package pyreqs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checkSince verifies that the first target directory is inside a git
+// repository that knows the Since ref. Otherwise a warning is raised and
+// every file is scanned as usual.
+func (g *Generator) checkSince() {
+	if g.since == "" {
+		return
+	}
+	if _, err := g.runGit(g.targetDirs[0], "rev-parse", "--verify", "--quiet", g.since+"^{commit}"); err != nil {
+		g.warn("cannot compare with the git ref, scanning every file", "ref", g.since, "error", err)
+		g.since = ""
+	}
+}
+
+// filterSince narrows the files found below dir to those changed since the
+// Since ref, plus the unchanged files that mention a module imported by an
+// earlier version of a changed or deleted file, since only those can still
+// need that module's package. The modules of the earlier versions are
+// recorded in sinceModules.
+func (g *Generator) filterSince(dir string, found []string) ([]string, error) {
+	diff, err := g.runGit(dir, "diff", "--name-only", "--relative", g.since, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := g.runGit(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool)
+	for _, rel := range outputLines(string(diff) + string(untracked)) {
+		changed[filepath.Join(dir, rel)] = true
+
+		// New files have no earlier version
+		old, err := g.runGit(dir, "show", g.since+":./"+rel)
+		if err != nil || !strings.HasSuffix(rel, ".py") {
+			continue
+		}
+		for _, module := range g.extractImportsFromPythonCode(normalizeSource(old)) {
+			if !isStandardLibrary(topLevelModule(module), g.targetPythonMinor) {
+				g.sinceModules[module] = true
+			}
+		}
+	}
+
+	// Unchanged files that may import one of the earlier modules
+	mentioning := make(map[string]bool)
+	if len(g.sinceModules) > 0 {
+		args := []string{"grep", "-l", "-w", "-F"}
+		for _, module := range sortedKeys(g.sinceModules) {
+			args = append(args, "-e", topLevelModule(module))
+		}
+		output, err := g.runGit(dir, append(args, "--")...)
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return nil, err
+		}
+		for _, rel := range outputLines(string(output)) {
+			mentioning[filepath.Join(dir, rel)] = true
+		}
+	}
+
+	var paths []string
+	for _, path := range found {
+		if changed[filepath.Clean(path)] || mentioning[filepath.Clean(path)] {
+			paths = append(paths, path)
+		}
+	}
+	g.logger.Debug("files to scan since git ref", "ref", g.since, "dir", dir, "changed", len(changed), "files", len(paths))
+	return paths, nil
+}
+
+// runGit runs git in dir under the pip timeout and returns its output.
+func (g *Generator) runGit(dir string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.pipTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// outputLines returns the non-empty lines of command output.
+func outputLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// mergeSince adds the requirements of the existing output file whose
+// packages no earlier version of a changed file imported: the unchanged
+// files still need them, and they were not scanned.
+func (g *Generator) mergeSince(requirements []string, installedPackages map[string]string) ([]string, error) {
+	existing, err := readRequirementsFile(g.outputFile)
+	if os.IsNotExist(err) {
+		return requirements, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Packages of the earlier modules are only kept when scanned files
+	// still import them
+	affected := make(map[string]bool)
+	for _, line := range g.requirementsOf(g.sinceModules, installedPackages) {
+		affected[requirementName(line)] = true
+	}
+	generated := make(map[string]bool)
+	for _, line := range requirements {
+		generated[requirementName(line)] = true
+	}
+
+	merged := append([]string{}, requirements...)
+	for _, line := range existing {
+		if name := requirementName(line); !generated[name] && !affected[name] {
+			merged = append(merged, line)
+		}
+	}
+	if g.sortOrder == SortName {
+		sort.SliceStable(merged, func(i, j int) bool {
+			return strings.ToLower(requirementDistribution(merged[i])) < strings.ToLower(requirementDistribution(merged[j]))
+		})
+	}
+	return merged, nil
+}
+
+// requirementsOf returns the requirement lines, runtime and dev, that
+// modules resolve to, leaving the results of the last Scan as they were.
+func (g *Generator) requirementsOf(modules map[string]bool, installedPackages map[string]string) []string {
+	foundModules, moduleOrder, packageModules, dependencyGraph := g.foundModules, g.moduleOrder, g.packageModules, g.dependencyGraph
+	directPackages, stats, warnings := g.directPackages, g.stats, g.warnings
+	defer func() {
+		g.foundModules, g.moduleOrder, g.packageModules, g.dependencyGraph = foundModules, moduleOrder, packageModules, dependencyGraph
+		g.directPackages, g.stats, g.warnings = directPackages, stats, warnings
+	}()
+
+	g.foundModules, g.moduleOrder, g.dependencyGraph = modules, make(map[string]int), make(map[string][]string)
+	g.directPackages = make(map[string]bool)
+	requirements, devRequirements, _ := g.generateRequirements(installedPackages)
+	return append(requirements, devRequirements...)
+}
@@ -0,0 +1,25 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequirementsOfKeepsScanResults(t *testing.T) {
+	g := newTestGenerator(Options{})
+	installed, err := g.parseFreeze([]byte("Flask==2.3.2\nrequests==2.31.0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.addModules("app.py", g.extractImportPositions("import requests\n"))
+	g.generateRequirements(installed)
+
+	want := []string{"Flask==2.3.2"}
+	if got := g.requirementsOf(map[string]bool{"flask": true}, installed); !reflect.DeepEqual(got, want) {
+		t.Errorf("requirementsOf(flask) = %q, want %q", got, want)
+	}
+	wantDirect := map[string]bool{"requests": true}
+	if !reflect.DeepEqual(g.directPackages, wantDirect) {
+		t.Errorf("directPackages = %v, want %v", g.directPackages, wantDirect)
+	}
+}
@@ -0,0 +1,55 @@
+package pyreqs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortOrder selects the order of the requirement lines.
+type SortOrder string
+
+const (
+	// SortName orders requirements by distribution name, ignoring case.
+	SortName SortOrder = "name"
+	// SortNone keeps the order in which the project first imports each
+	// package, following the files in walk order.
+	SortNone SortOrder = "none"
+)
+
+// ParseSortOrder converts a flag value into a SortOrder.
+func ParseSortOrder(value string) (SortOrder, error) {
+	switch order := SortOrder(value); order {
+	case SortName, SortNone:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown sort order '%s' (want name or none)", value)
+}
+
+// sortPackages orders the installed package names in place. modulesOf
+// returns the found modules a package provides, used for SortNone.
+func (g *Generator) sortPackages(names []string, modulesOf func(pkgName string) []string) {
+	// Start from a total order so ties are broken the same way every run
+	sort.Strings(names)
+
+	if g.sortOrder == SortNone {
+		firstImport := make(map[string]int, len(names))
+		for _, name := range names {
+			first := len(g.moduleOrder)
+			for _, module := range modulesOf(name) {
+				if index, ok := g.moduleOrder[module]; ok && index < first {
+					first = index
+				}
+			}
+			firstImport[name] = first
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			return firstImport[names[i]] < firstImport[names[j]]
+		})
+		return
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+}
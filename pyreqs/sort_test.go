@@ -0,0 +1,34 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortOrder(t *testing.T) {
+	freeze := "Django==4.2.3\nflask==2.3.2\nRequests==2.31.0\n"
+	content := "import requests\nimport flask\nimport django\n"
+	tests := []struct {
+		order SortOrder
+		want  []string
+	}{
+		{SortName, []string{"Django==4.2.3", "flask==2.3.2", "Requests==2.31.0"}},
+		{SortNone, []string{"Requests==2.31.0", "flask==2.3.2", "Django==4.2.3"}},
+	}
+	for _, tt := range tests {
+		if got := requirementsFor(t, Options{Sort: tt.order}, freeze, content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("requirements sorted by %s = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for _, value := range []string{"name", "none"} {
+		if order, err := ParseSortOrder(value); err != nil || string(order) != value {
+			t.Errorf("ParseSortOrder(%q) = %q, %v", value, order, err)
+		}
+	}
+	if _, err := ParseSortOrder("size"); err == nil {
+		t.Error("ParseSortOrder(\"size\") succeeded, want an error")
+	}
+}
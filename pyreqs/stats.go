@@ -0,0 +1,30 @@
+package pyreqs
+
+import "time"
+
+// Stats summarizes a Scan, to explain how the imports found became the
+// requirements.
+type Stats struct {
+	// FilesScanned counts the files whose imports were read.
+	FilesScanned int
+	// ImportsFound counts the distinct modules imported by those files.
+	ImportsFound int
+	// Stdlib, Local and Ignored count the imports skipped as standard
+	// library, project-local or ignored by configuration.
+	Stdlib  int
+	Local   int
+	Ignored int
+	// Matched counts the imports provided by an installed package.
+	Matched int
+	// Unresolved counts the imports no installed package provides.
+	Unresolved int
+	// Requirements counts the requirement lines, including dev requirements.
+	Requirements int
+	// Elapsed is the duration of the Scan.
+	Elapsed time.Duration
+}
+
+// Stats returns the statistics of the last Scan.
+func (g *Generator) Stats() Stats {
+	return g.stats
+}
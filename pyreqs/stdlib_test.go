@@ -0,0 +1,63 @@
+package pyreqs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsStandardLibrary(t *testing.T) {
+	tests := []struct {
+		module string
+		minor  int
+		want   bool
+	}{
+		{"os", 8, true},
+		{"tomllib", 10, false},
+		{"tomllib", 11, true},
+		{"distutils", 11, true},
+		{"distutils", 12, false},
+		{"telnetlib", 12, true},
+		{"telnetlib", 13, false},
+		{"tomllib", 0, true},
+		{"distutils", 0, true},
+		{"requests", 0, false},
+	}
+	for _, tt := range tests {
+		if got := isStandardLibrary(tt.module, tt.minor); got != tt.want {
+			t.Errorf("isStandardLibrary(%q, %d) = %v, want %v", tt.module, tt.minor, got, tt.want)
+		}
+	}
+}
+
+func TestTargetPythonVersionUnresolved(t *testing.T) {
+	tests := []struct {
+		minor int
+		want  []string
+	}{
+		{10, []string{"tomllib"}},
+		{11, nil},
+		{12, []string{"distutils"}},
+	}
+	for _, tt := range tests {
+		g := newTestGenerator(Options{TargetPythonMinor: tt.minor})
+		g.addModules("app.py", g.extractImportPositions("import distutils\nimport tomllib\n"))
+		_, _, unresolved := g.generateRequirements(map[string]string{})
+		if !reflect.DeepEqual(unresolved, tt.want) {
+			t.Errorf("unresolved for Python 3.%d = %v, want %v", tt.minor, unresolved, tt.want)
+		}
+	}
+}
+
+func TestParsePythonVersion(t *testing.T) {
+	tests := map[string]int{"3.11": 11, "3.8": 8, "Python 3.12.1": 12, " 3.10.4\n": 10}
+	for value, want := range tests {
+		if got, err := ParsePythonVersion(value); err != nil || got != want {
+			t.Errorf("ParsePythonVersion(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"2.7", "3", "python3"} {
+		if _, err := ParsePythonVersion(value); err == nil {
+			t.Errorf("ParsePythonVersion(%q) succeeded, want an error", value)
+		}
+	}
+}
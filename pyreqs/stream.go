@@ -0,0 +1,108 @@
+package pyreqs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// largeFileSize is the size above which a Python file is scanned line by line
+// instead of being read into memory whole, e.g. for generated code.
+const largeFileSize = 8 << 20
+
+// maxLineSize bounds the longest line of a streamed file; longer lines, such
+// as those of minified code, fail the scan of that file.
+const maxLineSize = 64 << 20
+
+// scanLargeFile extracts the imports of the Python file at path while reading
+// it line by line.
+func (g *Generator) scanLargeFile(path string) scanResult {
+	file, err := os.Open(path)
+	if err != nil {
+		return scanResult{path: path, err: err}
+	}
+	defer file.Close()
+
+	imports, pythonMinor, err := g.extractImportsFromReader(file)
+	return scanResult{path: path, imports: imports, pythonMinor: pythonMinor, err: err}
+}
+
+// extractImportsFromReader is the streaming counterpart of
+// extractImportsFromPythonCode and detectPythonVersion: it applies the same
+// directives, comment, docstring and TYPE_CHECKING stripping and line joining
+// one line at a time, carrying their state from line to line.
+func (g *Generator) extractImportsFromReader(r io.Reader) (imports []Import, pythonMinor int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	delimiter := "" // closing delimiter of the triple-quoted string we are in
+	typeChecking := typeCheckingFilter{blockIndent: -1}
+	var statement string // backslash-continued lines joined so far
+	var previous string  // last non-blank statement, for multi-line syntax
+	start := 1           // line the statement starts on
+	for number := 1; scanner.Scan(); number++ {
+		first := number == 1
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if first {
+			line = strings.TrimPrefix(line, string(utf8BOM))
+		}
+
+		if match := directiveRegex.FindStringSubmatch(line); match != nil {
+			if match[1] == "ignore-file" && first {
+				return nil, 0, nil
+			}
+			if match[1] == "ignore" {
+				line = ""
+			}
+		}
+
+		if g.scanDoctests {
+			if match := doctestImportRegex.FindStringSubmatch(line); match != nil {
+				imports = append(imports, g.importsAt(match[1], number)...)
+			}
+		}
+
+		if delimiter == "" && strings.HasPrefix(strings.TrimSpace(line), "#") {
+			line = ""
+		} else {
+			line = stripTripleQuoted(line, &delimiter)
+		}
+		if g.excludeTypeChecking && typeChecking.skip(line) {
+			line = ""
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			statement += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		statement += line
+
+		imports = append(imports, g.importsAt(statement, start)...)
+		if strings.TrimSpace(statement) != "" {
+			if minor := detectPythonVersion(previous+"\n"+statement, nil); minor > pythonMinor {
+				pythonMinor = minor
+			}
+			previous = statement
+		}
+		statement = ""
+		start = number + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if minor := detectPythonVersion("", importNames(imports)); minor > pythonMinor {
+		pythonMinor = minor
+	}
+	return imports, pythonMinor, nil
+}
+
+// importsAt returns the imports of a single statement starting on line.
+func (g *Generator) importsAt(statement string, line int) []Import {
+	imports := g.matchImportPositions(statement)
+	for i := range imports {
+		imports[i].Line = line
+	}
+	return imports
+}
@@ -0,0 +1,71 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamingMatchesInMemory(t *testing.T) {
+	tests := []string{
+		"import requests\nfrom flask import Flask\n",
+		"\"\"\"Module docstring.\n\nimport numpy\n\"\"\"\nimport six\n",
+		"x = '''\nimport pandas\n'''  ; import yaml\n",
+		"# import numpy\nimport requests  # ; import flask\n",
+		"import requests, \\\n    six\n",
+		"import os; import requests\n",
+		"import numpy  # pyreqs: ignore\nimport six\n",
+		"if TYPE_CHECKING:\n    import numpy\nimport six\n",
+	}
+	for _, opts := range []Options{{}, {ExcludeTypeChecking: true}} {
+		g := newTestGenerator(opts)
+		for _, content := range tests {
+			want := g.extractImportsFromPythonCode(content)
+			imports, _, err := g.extractImportsFromReader(strings.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := importNames(imports); !reflect.DeepEqual(got, want) {
+				t.Errorf("streamed imports of %q (exclude TYPE_CHECKING %v) = %v, want %v",
+					content, opts.ExcludeTypeChecking, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkScan compares scanning a 50MB generated file line by line, as
+// scanFile does above largeFileSize, with reading it whole.
+func BenchmarkScan(b *testing.B) {
+	block := "import requests\nfrom flask import Flask\n\"\"\"\nimport numpy\n\"\"\"\n" +
+		"DATA = [" + strings.Repeat("0x00, ", 150) + "]\n"
+	path := filepath.Join(b.TempDir(), "generated.py")
+	content := strings.Repeat(block, 50<<20/len(block))
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	content = ""
+	g := newTestGenerator(Options{})
+
+	b.Run("streamed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if result := g.scanFile(path); result.err != nil || len(result.imports) == 0 {
+				b.Fatalf("scanFile: %d imports, %v", len(result.imports), result.err)
+			}
+		}
+	})
+	b.Run("in-memory", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			source, err := readPythonSource(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if imports := g.extractImportPositions(source); len(imports) == 0 {
+				b.Fatal("no imports found")
+			}
+		}
+	})
+}
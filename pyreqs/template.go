@@ -0,0 +1,61 @@
+package pyreqs
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what an output template is executed with: the Report of
+// the last Scan, so {{range .Requirements}} and {{.Unresolved}} work as in
+// the JSON report, and its Stats.
+type TemplateData struct {
+	Report
+	Stats Stats
+}
+
+// templateFuncs are the functions available to output templates besides the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+}
+
+// ParseTemplateFile parses the text/template file at path for
+// Options.Template, with "join" and "lower" functions.
+func ParseTemplateFile(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+}
+
+// Sources returns the files importing the requirement's package, in the
+// order of Imports, each once.
+func (r Requirement) Sources() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, site := range r.Imports {
+		if !seen[site.File] {
+			seen[site.File] = true
+			files = append(files, site.File)
+		}
+	}
+	return files
+}
+
+func (g *Generator) writeTemplate() error {
+	file, err := createOutputFile(g.outputFile)
+	if err != nil {
+		return err
+	}
+	if err := g.WriteTemplate(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteTemplate executes the Template of the Options with the TemplateData
+// of the last Scan and writes the result to w.
+func (g *Generator) WriteTemplate(w io.Writer) error {
+	return g.template.Execute(w, TemplateData{Report: g.Report(), Stats: g.stats})
+}
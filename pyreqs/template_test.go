@@ -0,0 +1,101 @@
+package pyreqs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// templateReport is a known Report for executing templates against.
+var templateReport = TemplateData{
+	Report: Report{
+		Requirements: []Requirement{
+			{Name: "Flask", Version: "2.3.2", Line: "Flask==2.3.2", Imports: []ImportSite{
+				{Module: "flask", File: "app.py", Line: 1},
+				{Module: "flask", File: "views/home.py", Line: 3},
+				{Module: "flask", File: "app.py", Line: 7},
+			}},
+			{Name: "mypkg", Line: "mypkg @ git+https://github.com/acme/mypkg.git"},
+		},
+		Unresolved: []string{"acme", "internal"},
+	},
+	Stats: Stats{FilesScanned: 2},
+}
+
+func TestTemplateFixture(t *testing.T) {
+	tmpl, err := ParseTemplateFile(filepath.Join("testdata", "templates", "summary.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, templateReport); err != nil {
+		t.Fatal(err)
+	}
+	want := `2 files, 2 requirements
+flask 2.3.2: app.py, views/home.py
+  app.py:1 imports flask
+  views/home.py:3 imports flask
+  app.py:7 imports flask
+mypkg -
+unresolved: acme, internal
+`
+	if got := b.String(); got != want {
+		t.Errorf("template output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExampleTemplatesParse(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "examples", "templates", "*.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		tmpl, err := ParseTemplateFile(path)
+		if err != nil {
+			t.Errorf("ParseTemplateFile(%s): %v", path, err)
+			continue
+		}
+		if err := tmpl.Execute(new(strings.Builder), templateReport); err != nil {
+			t.Errorf("executing %s: %v", path, err)
+		}
+	}
+}
+
+func TestBadTemplates(t *testing.T) {
+	if _, err := ParseTemplateFile(filepath.Join("testdata", "templates", "unclosed.tmpl")); err == nil {
+		t.Error("ParseTemplateFile(unclosed.tmpl) succeeded, want a parse error")
+	}
+	if _, err := ParseTemplateFile(filepath.Join("testdata", "templates", "missing.tmpl")); err == nil {
+		t.Error("ParseTemplateFile(missing.tmpl) succeeded, want an error")
+	}
+
+	// Unknown fields only fail once the template is executed
+	tmpl, err := ParseTemplateFile(filepath.Join("testdata", "templates", "unknown-field.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newTestGenerator(Options{Template: tmpl})
+	g.requirements = []string{"requests==2.31.0"}
+	if err := g.WriteTemplate(new(strings.Builder)); err == nil || !strings.Contains(err.Error(), "Hash") {
+		t.Errorf("WriteTemplate error = %v, want one naming the unknown field", err)
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.py": "import requests\nimport notinstalled\n"})
+	tmpl, err := ParseTemplateFile(filepath.Join("testdata", "templates", "summary.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ := scanWithFreeze(t, Options{TargetDir: dir, Template: tmpl}, "requests==2.31.0\n")
+	var b strings.Builder
+	if err := g.WriteTemplate(&b); err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(dir, "app.py")
+	want := "1 files, 1 requirements\nrequests 2.31.0: " + app + "\n  " + app + ":1 imports requests\nunresolved: notinstalled\n"
+	if got := b.String(); got != want {
+		t.Errorf("template output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,3 @@
+import numpy as np, pandas as pd
+import os.path as osp
+from six.moves import range
@@ -0,0 +1,8 @@
+import os
+import sys
+
+import requests
+from flask import Flask, jsonify
+
+
+app = Flask(__name__)
@@ -0,0 +1,2 @@
+import yaml
+from PIL import Image
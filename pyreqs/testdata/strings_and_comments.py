@@ -0,0 +1,8 @@
+import json  # ; import pandas
+
+HELP = 'first do this; import numpy and retry'
+QUERY = "BEGIN; from flask import Flask"
+
+
+def main():
+	import requests; import six
@@ -0,0 +1,4 @@
+from __future__ import annotations
+
+import notinstalled
+import requests
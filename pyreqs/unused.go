@@ -0,0 +1,58 @@
+package pyreqs
+
+import (
+	"sort"
+	"strings"
+)
+
+// packagingTools are installed in nearly every environment to manage it, so
+// they are never reported as unused.
+var packagingTools = map[string]bool{
+	"pip":        true,
+	"setuptools": true,
+	"wheel":      true,
+	"distribute": true,
+}
+
+// findUnused returns the sorted distribution names of the installed packages
+// that no scanned module resolves to. Packages that another installed package
+// requires, as read from "pip show", are left out as transitive
+// dependencies; when pip show cannot be run, they are kept and a warning
+// says so.
+func (g *Generator) findUnused(installedPackages map[string]string) []string {
+	var candidates []string
+	for _, pkgName := range sortedKeys(installedPackages) {
+		normalized := pep503Normalize(pkgName)
+		if _, imported := g.packageModules[normalized]; imported || packagingTools[normalized] {
+			continue
+		}
+		candidates = append(candidates, pkgName)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	required := make(map[string]bool)
+	if g.freezeFile != "" {
+		g.warn("unused packages may include transitive dependencies, pip show is not run with a freeze file")
+	} else if requires, err := g.requiresOf(sortedKeys(installedPackages)); err != nil {
+		g.warn("could not read package dependencies, unused packages may include transitive dependencies", "error", err)
+	} else {
+		for _, dependencies := range requires {
+			for _, dependency := range dependencies {
+				required[pep503Normalize(dependency)] = true
+			}
+		}
+	}
+
+	var unused []string
+	for _, pkgName := range candidates {
+		if required[pep503Normalize(pkgName)] {
+			g.logger.Debug("not imported but required by another package, not reported as unused", "package", pkgName)
+			continue
+		}
+		unused = append(unused, requirementDistribution(installedPackages[pkgName]))
+	}
+	sort.Slice(unused, func(i, j int) bool { return strings.ToLower(unused[i]) < strings.ToLower(unused[j]) })
+	return unused
+}
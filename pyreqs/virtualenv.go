@@ -0,0 +1,30 @@
+package pyreqs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// virtualenvDirs lists the directory names checked for a project virtualenv.
+var virtualenvDirs = []string{".venv", "venv"}
+
+// detectVirtualenv returns the path of a virtual environment directly inside
+// dir, recognized by the Python interpreter it contains.
+func detectVirtualenv(dir string) (string, bool) {
+	for _, name := range virtualenvDirs {
+		venv := filepath.Join(dir, name)
+		if info, err := os.Stat(virtualenvPython(venv)); err == nil && !info.IsDir() {
+			return venv, true
+		}
+	}
+	return "", false
+}
+
+// virtualenvPython returns the interpreter path inside a virtual environment.
+func virtualenvPython(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts", "python.exe")
+	}
+	return filepath.Join(venv, "bin", "python")
+}
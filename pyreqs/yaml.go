@@ -0,0 +1,56 @@
+package pyreqs
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlReport is the document written by FormatYAML: the requirement lines
+// and unresolved modules of the Report, in that order.
+type yamlReport struct {
+	Requirements []string `yaml:"requirements"`
+	Unresolved   []string `yaml:"unresolved"`
+}
+
+func (g *Generator) writeYAML() error {
+	file, err := createOutputFile(g.outputFile)
+	if err != nil {
+		return err
+	}
+	if err := g.WriteYAML(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteYAML writes the requirement lines and unresolved modules of the last
+// Scan to w as a YAML document, e.g. for Helm values or Ansible vars.
+func (g *Generator) WriteYAML(w io.Writer) error {
+	report := yamlReport{
+		Requirements: append([]string{}, g.requirements...),
+		Unresolved:   append([]string{}, g.unresolved...),
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// readYAMLRequirements returns the requirement lines of a YAML report.
+func readYAMLRequirements(path string) ([]string, error) {
+	data, err := readOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report yamlReport
+	if err := yaml.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return report.Requirements, nil
+}
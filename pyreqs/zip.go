@@ -0,0 +1,86 @@
+package pyreqs
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// isZipArchive reports whether target is a .zip file rather than a directory.
+func isZipArchive(target string) bool {
+	if !strings.EqualFold(filepath.Ext(target), ".zip") {
+		return false
+	}
+	info, err := os.Stat(target)
+	return err == nil && !info.IsDir()
+}
+
+// scanZip extracts the imports of the Python files in the zip archive at
+// archive, applying the same directory and file filters as a directory walk.
+// Files are reported under the archive path, e.g. "project.zip/app/main.py".
+func (g *Generator) scanZip(archive string) ([]scanResult, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %v", archive, err)
+	}
+	defer reader.Close()
+
+	var results []scanResult
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !g.scannedZipEntry(file.Name) {
+			continue
+		}
+
+		filePath := filepath.Join(archive, filepath.FromSlash(file.Name))
+		if strings.HasSuffix(file.Name, ".py") {
+			g.recordLocalModule(filePath)
+		}
+		if isTestFile(archive, filePath) {
+			g.testFiles[filePath] = true
+		}
+
+		content, err := readZipEntry(file)
+		if err != nil {
+			results = append(results, scanResult{path: filePath, err: err})
+			continue
+		}
+		imports := g.extractImports(filePath, content)
+		results = append(results, scanResult{path: filePath, imports: imports, pythonMinor: detectPythonVersion(content, importNames(imports))})
+	}
+	return results, nil
+}
+
+// scannedZipEntry reports whether the archive entry name is a file to scan:
+// a .py file or one matching an include pattern, outside excluded
+// directories and not matching an exclude pattern.
+func (g *Generator) scannedZipEntry(name string) bool {
+	dir, base := path.Split(name)
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if g.excludedDirs[part] {
+			return false
+		}
+	}
+	if matchAny(g.excludePatterns, base) {
+		return false
+	}
+	return strings.HasSuffix(base, ".py") || matchAny(g.includePatterns, base)
+}
+
+// readZipEntry returns the contents of an archived Python file.
+func readZipEntry(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return normalizeSource(content), nil
+}
@@ -0,0 +1,81 @@
+package pyreqs
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeZip writes an archive at path with files, keyed by entry name.
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var b bytes.Buffer
+	writer := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanZip(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.zip")
+	writeZip(t, archive, map[string]string{
+		"app/main.py":               "import requests\nfrom app import helpers\n",
+		"app/helpers.py":            "import six\n",
+		"venv/lib/site/vendored.py": "import flask\n",
+	})
+
+	g, requirements := scanWithFreeze(t, Options{TargetDirs: []string{archive}}, "Flask==2.3.2\nrequests==2.31.0\nsix==1.16.0\n")
+	if want := []string{"requests==2.31.0", "six==1.16.0"}; !reflect.DeepEqual(requirements, want) {
+		t.Errorf("requirements = %v, want %v", requirements, want)
+	}
+	if n := g.Stats().FilesScanned; n != 2 {
+		t.Errorf("scanned %d files, want 2", n)
+	}
+
+	sites := make(map[string][]ImportSite)
+	for _, req := range g.Report().Requirements {
+		sites[req.Name] = req.Imports
+	}
+	want := map[string][]ImportSite{
+		"requests": {{Module: "requests", File: filepath.Join(archive, "app", "main.py"), Line: 1}},
+		"six":      {{Module: "six", File: filepath.Join(archive, "app", "helpers.py"), Line: 1}},
+	}
+	if !reflect.DeepEqual(sites, want) {
+		t.Errorf("import sites = %+v, want %+v", sites, want)
+	}
+}
+
+func TestScannedZipEntry(t *testing.T) {
+	g := newTestGenerator(Options{ExcludeDirs: []string{"build"}, ExcludePatterns: []string{"*_pb2.py"}, IncludePatterns: []string{"*.pyi"}})
+	tests := map[string]bool{
+		"main.py":                true,
+		"app/main.py":            true,
+		"app/types.pyi":          true,
+		"app/README.md":          false,
+		"venv/lib/site.py":       false,
+		"app/__pycache__/x.py":   false,
+		"build/lib/app/main.py":  false,
+		"app/proto/api_pb2.py":   false,
+		"app/build_tools/gen.py": true,
+	}
+	for name, want := range tests {
+		if got := g.scannedZipEntry(name); got != want {
+			t.Errorf("scannedZipEntry(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/LaamiriOuail/go-pyreqs/pyreqs"
+)
+
+// watchDebounce is how long watch waits after the last change before
+// regenerating, so that saving many files at once triggers a single run.
+const watchDebounce = 500 * time.Millisecond
+
+// watch runs run once and then again whenever a scanned file below the
+// target directories, or a target that is a file, changes, until the process
+// is interrupted. Errors of individual runs are reported without stopping the
+// watch.
+func watch(opts pyreqs.Options, cli cliOptions) error {
+	if err := run(opts, cli); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	// Having started the watch, regenerating is what the user asked for
+	cli.yes = true
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %v", err)
+	}
+	defer watcher.Close()
+
+	excluded := make(map[string]bool)
+	for _, dir := range append(append([]string{}, pyreqs.DefaultExcludedDirs...), opts.ExcludeDirs...) {
+		excluded[dir] = true
+	}
+	// Absolute paths of the directories watched below directory targets, and
+	// of the targets that are files. Those are watched through their parent
+	// directory, which also sees editors that save by renaming a new file
+	// over the old one, and events for its other files are dropped
+	trees := make(map[string]bool)
+	files := make(map[string]bool)
+	for _, target := range opts.TargetDirs {
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			abs, err := filepath.Abs(target)
+			if err == nil {
+				err = watcher.Add(filepath.Dir(abs))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to watch '%s': %v", target, err)
+			}
+			files[abs] = true
+			continue
+		}
+		if err := watchTree(watcher, target, excluded, trees); err != nil {
+			return fmt.Errorf("failed to watch '%s': %v", target, err)
+		}
+	}
+
+	// Writing the output must not trigger another run, e.g. with -format setup
+	generator := pyreqs.NewGenerator(opts)
+	outputs := make(map[string]bool)
+	for _, path := range []string{generator.OutputFile(), generator.DevOutputFile()} {
+		if abs, err := filepath.Abs(path); err == nil && path != "" {
+			outputs[abs] = true
+		}
+	}
+
+	cli.printf("Watching for changes (press Ctrl+C to stop)...\n")
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			abs, err := filepath.Abs(event.Name)
+			if err != nil || outputs[abs] {
+				continue
+			}
+			inTree := trees[filepath.Dir(abs)]
+			if inTree && event.Op&fsnotify.Create != 0 {
+				// New directories are watched too; adding a file fails harmlessly
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchTree(watcher, event.Name, excluded, trees)
+				}
+			}
+			if files[abs] || inTree && watchedFile(event.Name, opts) {
+				timer = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			cli.logger.Warn("file watcher error", "error", err)
+		case <-timer:
+			timer = nil
+			cli.printf("\nChange detected, regenerating...\n")
+			if err := run(opts, cli); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}
+	}
+}
+
+// watchTree adds dir and every directory below it, except excluded ones, to
+// watcher, since fsnotify does not watch recursively, and records their
+// absolute paths in trees.
+func watchTree(watcher *fsnotify.Watcher, dir string, excluded, trees map[string]bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if path != dir && excluded[info.Name()] {
+			return filepath.SkipDir
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		trees[abs] = true
+		return watcher.Add(path)
+	})
+}
+
+// watchedFile reports whether a change to path can affect the requirements:
+// it is a Python file, a scanned notebook or matches an -include pattern.
+func watchedFile(path string, opts pyreqs.Options) bool {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".py"):
+		return true
+	case opts.IncludeNotebooks && strings.HasSuffix(name, ".ipynb"):
+		return true
+	}
+	for _, pattern := range opts.IncludePatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}